@@ -0,0 +1,131 @@
+package main
+
+import (
+    "math"
+
+    "fourier-drawing/engine"
+)
+
+const (
+    CAMERA_ZOOM_STEP = 1.15
+    CAMERA_MIN_ZOOM = 0.25
+    CAMERA_MAX_ZOOM = 500.0
+)
+
+// Camera maps board coordinates, the ones points are stored in, to screen coordinates.
+// Only positions go through it: line widths and dot sizes stay in screen pixels, so
+// zooming in shows more detail instead of thicker strokes.
+type Camera struct {
+    // x, y is the board point shown at the centre of the screen.
+    x, y            float64
+    zoom            float64
+    width, height   float64
+    // follow keeps the tip of the epicycle chain at the centre while FOURIER runs.
+    follow          bool
+    dragging        bool
+    // pinching lasts from the second finger down until every finger is lifted.
+    pinching        bool
+}
+
+func newCamera(width, height int) Camera {
+    c := Camera{width: float64(width), height: float64(height)}
+    c.Reset()
+    return c
+}
+
+// Reset shows the whole board again at its natural size.
+func (c *Camera) Reset() {
+    c.x, c.y = c.width/2, c.height/2
+    c.zoom = 1
+}
+
+func (c *Camera) ToScreen(x, y float64) (float64, float64) {
+    return (x-c.x)*c.zoom+c.width/2, (y-c.y)*c.zoom+c.height/2
+}
+
+func (c *Camera) ToWorld(x, y float64) (float64, float64) {
+    return (x-c.width/2)/c.zoom+c.x, (y-c.height/2)/c.zoom+c.y
+}
+
+// ToScreenPath returns the screen points of path.
+func (c *Camera) ToScreenPath(path []engine.Vec) []Point {
+    out := make([]Point, len(path))
+    for i, p := range path {
+        out[i].x, out[i].y = c.ToScreen(p.X, p.Y)
+    }
+    return out
+}
+
+// Visible reports whether a circle given in screen coordinates overlaps the screen.
+func (c *Camera) Visible(x, y, radius float64) bool {
+    return x+radius >= 0 && y+radius >= 0 && x-radius <= c.width && y-radius <= c.height
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the board point under the screen
+// point (x, y) in place.
+func (c *Camera) ZoomAt(x, y, factor float64) {
+    wx, wy := c.ToWorld(x, y)
+    c.zoom = math.Max(CAMERA_MIN_ZOOM, math.Min(CAMERA_MAX_ZOOM, c.zoom*factor))
+    nx, ny := c.ToWorld(x, y)
+    c.x += wx-nx
+    c.y += wy-ny
+}
+
+// Follow centres the camera on (x, y) when follow mode is on.
+func (c *Camera) Follow(x, y float64) {
+    if c.follow {
+        c.x, c.y = x, y
+    }
+}
+
+// Update zooms with the mouse wheel and pans while the right or middle button is
+// dragged; on a touchscreen two fingers pinch to zoom and move together to pan.
+// None of these starts over a widget, and panning by hand leaves follow mode.
+func (c *Camera) Update(frame, previous PointerFrame, pointerCaptured bool) {
+    if frame.Wheel != 0 && !pointerCaptured {
+        if c.follow {
+            c.ZoomAt(c.width/2, c.height/2, math.Pow(CAMERA_ZOOM_STEP, frame.Wheel))
+        } else {
+            c.ZoomAt(frame.X, frame.Y, math.Pow(CAMERA_ZOOM_STEP, frame.Wheel))
+        }
+    }
+
+    if frame.Panning && c.dragging && (frame.X != previous.X || frame.Y != previous.Y) {
+        c.pan(frame.X-previous.X, frame.Y-previous.Y)
+    }
+    c.dragging = frame.Panning && (c.dragging || !pointerCaptured)
+
+    if len(frame.Contacts) >= 2 {
+        if !c.pinching && !pointerCaptured {
+            c.pinching = true
+        } else if c.pinching && len(previous.Contacts) >= 2 && sameContacts(frame.Contacts[:2], previous.Contacts[:2]) {
+            x0, y0, d0 := midpoint(previous.Contacts)
+            x1, y1, d1 := midpoint(frame.Contacts)
+            if x1 != x0 || y1 != y0 {
+                c.pan(x1-x0, y1-y0)
+            }
+            if d0 > 0 {
+                c.ZoomAt(x1, y1, d1/d0)
+            }
+        }
+    } else if len(frame.Contacts) == 0 {
+        c.pinching = false
+    }
+}
+
+// pan moves the view by a screen space offset.
+func (c *Camera) pan(dx, dy float64) {
+    c.x -= dx/c.zoom
+    c.y -= dy/c.zoom
+    c.follow = false
+}
+
+// midpoint returns the middle of the first two contacts and their distance.
+func midpoint(contacts []Pointer) (x, y, distance float64) {
+    a, b := contacts[0], contacts[1]
+    return (a.X+b.X)/2, (a.Y+b.Y)/2, math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+func sameContacts(a, b []Pointer) bool {
+    return a[0].ID == b[0].ID && a[1].ID == b[1].ID
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+    "math"
+    "testing"
+)
+
+func touches(points ...[2]float64) PointerFrame {
+    frame := PointerFrame{X: points[0][0], Y: points[0][1]}
+    for i, p := range points {
+        frame.Contacts = append(frame.Contacts, Pointer{ID: i+1, X: p[0], Y: p[1]})
+    }
+    return frame
+}
+
+// run feeds frames to the camera one tick at a time, as Game.Update does.
+func run(c *Camera, pointerCaptured bool, frames ...PointerFrame) {
+    var previous PointerFrame
+    for _, frame := range frames {
+        c.Update(frame, previous, pointerCaptured)
+        previous = frame
+    }
+}
+
+func near(a, b float64) bool {
+    return math.Abs(a-b) < 1e-9
+}
+
+func TestPinchZoomsAboutTheMidpoint(t *testing.T) {
+    c := newCamera(1920, 1080)
+    wx, wy := c.ToWorld(1000, 500)
+    run(&c, false,
+        touches([2]float64{900, 500}, [2]float64{1100, 500}),
+        touches([2]float64{800, 500}, [2]float64{1200, 500}),
+    )
+    if (!near(c.zoom, 2)) {
+        t.Errorf("zoom after spreading the fingers twice as far = %v, want 2", c.zoom)
+    }
+    if x, y := c.ToWorld(1000, 500); !near(x, wx) || !near(y, wy) {
+        t.Errorf("board point under the midpoint moved from (%v, %v) to (%v, %v)", wx, wy, x, y)
+    }
+}
+
+func TestTwoFingersPan(t *testing.T) {
+    c := newCamera(1920, 1080)
+    c.follow = true
+    x, y := c.x, c.y
+    run(&c, false,
+        touches([2]float64{900, 500}, [2]float64{1100, 500}),
+        touches([2]float64{930, 480}, [2]float64{1130, 480}),
+    )
+    if (!near(c.zoom, 1) || !near(c.x, x-30) || !near(c.y, y+20)) {
+        t.Errorf("camera after moving both fingers by (30, -20) = (%v, %v) at zoom %v, want (%v, %v) at zoom 1", c.x, c.y, c.zoom, x-30, y+20)
+    }
+    if (c.follow) {
+        t.Error("panning by hand left follow mode on")
+    }
+}
+
+func TestDragPans(t *testing.T) {
+    c := newCamera(1920, 1080)
+    c.ZoomAt(c.width/2, c.height/2, 2)
+    x, y := c.x, c.y
+    run(&c, false,
+        PointerFrame{X: 500, Y: 500, Panning: true},
+        PointerFrame{X: 540, Y: 520, Panning: true},
+    )
+    if (!near(c.x, x-20) || !near(c.y, y-10)) {
+        t.Errorf("camera after dragging by (40, 20) at zoom 2 = (%v, %v), want (%v, %v)", c.x, c.y, x-20, y-10)
+    }
+}
+
+func TestNothingStartsOverAWidget(t *testing.T) {
+    c := newCamera(1920, 1080)
+    run(&c, true,
+        PointerFrame{X: 500, Y: 500, Panning: true, Wheel: 1},
+        PointerFrame{X: 540, Y: 520, Panning: true},
+        touches([2]float64{900, 500}, [2]float64{1100, 500}),
+        touches([2]float64{800, 500}, [2]float64{1200, 500}),
+    )
+    if (c.x != c.width/2 || c.y != c.height/2 || c.zoom != 1) {
+        t.Errorf("camera moved to (%v, %v) at zoom %v by gestures over a widget", c.x, c.y, c.zoom)
+    }
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "math"
+    "math/cmplx"
+    "os"
+    "strings"
+
+    "fourier-drawing/fourier"
+    "fourier-drawing/record"
+)
+
+// Options holds the command-line configuration of the interactive app.
+type Options struct {
+    input           string
+    width           int
+    height          int
+    fullscreen      bool
+    startState      GameState
+    maxEpicycles    int
+    byTime          bool
+    speed           float64
+    dots            bool
+    epicycles       bool
+    keymap          string
+    theme           string
+    themesDir       string
+    trailStyle      TrailStyle
+    trailLength     int
+    epicycleStyle   EpicycleStyle
+    filter          SpectrumFilter
+    normalization   Normalization
+    window          SpectrumWindow
+    magnifier       bool
+    magnifierZoom   float64
+    recordFormat    string
+    recordOutput    string
+    recordFPS       float64
+    replay          string
+    replayExit      bool
+    recordInput     string
+    playlist        string
+    morph           string
+    morphMode       MorphMode
+    morphSeconds    float64
+    morphPoints     int
+}
+
+var startStates = map[string]GameState{
+    "start":    START,
+    "drawing":  DRAWING,
+    "reveal":   REVEALING,
+    "fourier":  COMPUTING,
+    "gallery":  GALLERY,
+}
+
+func parseOptions(args []string) Options {
+    var options Options
+    var start, trail, morphMode string
+
+    flags := flag.NewFlagSet("fourier-drawing", flag.ExitOnError)
+    flags.Usage = func() {
+        fmt.Fprintf(flags.Output(), "Usage:\n  fourier-drawing [flags]\n  fourier-drawing compute [flags] <points file>\n  fourier-drawing sheet [flags] <points file>\n  fourier-drawing svg [flags] <points file>\n\nFlags:\n")
+        flags.PrintDefaults()
+    }
+    flags.StringVar(&options.input, "input", "", "point file to load at startup")
+    flags.IntVar(&options.width, "width", 1920, "window width")
+    flags.IntVar(&options.height, "height", 1080, "window height")
+    flags.BoolVar(&options.fullscreen, "fullscreen", false, "start in fullscreen mode")
+    flags.StringVar(&start, "start", "start", "starting screen: start, drawing, reveal, fourier or gallery")
+    flags.IntVar(&options.maxEpicycles, "max-epicycles", 0, "number of epicycles to keep, largest first (0 keeps all)")
+    flags.BoolVar(&options.byTime, "by-time", false, "play timed drawings back with the rhythm they were drawn with")
+    flags.Float64Var(&options.speed, "speed", 1.0, "playback speed in points per tick")
+    flags.BoolVar(&options.dots, "dots", false, "enable the points visualization")
+    flags.BoolVar(&options.epicycles, "epicycles", false, "enable the epicycles visualization")
+    flags.StringVar(&options.keymap, "keymap", defaultKeymapPath(), "JSON file with custom key bindings")
+    flags.StringVar(&options.theme, "theme", "dark", "color theme: dark, light, high-contrast or a user theme name")
+    flags.StringVar(&options.themesDir, "themes-dir", userThemesDir(), "directory with user JSON themes")
+    flags.StringVar(&trail, "trail", "solid", "trail style: solid, comet, gradient, speed or glow")
+    flags.IntVar(&options.trailLength, "trail-length", 300, "number of segments kept by the comet trail")
+    flags.BoolVar(&options.epicycleStyle.colorByFrequency, "color-by-frequency", false, "color epicycles by frequency sign and magnitude")
+    flags.Float64Var(&options.epicycleStyle.minRadius, "min-radius", 0, "hide epicycles with a radius below this many pixels")
+    flags.BoolVar(&options.epicycleStyle.arrows, "arrows", false, "draw arrowheads on the radius vectors")
+    flags.BoolVar(&options.epicycleStyle.filledDiscs, "filled-discs", false, "fill the epicycles with translucent discs")
+    checkFilter := addFilterFlags(flags, &options.filter)
+    checkNormalize := addNormalizeFlags(flags, &options.normalization)
+    checkWindow := addWindowFlags(flags, &options.window)
+    flags.BoolVar(&options.magnifier, "magnifier", false, "show a magnified view of the tip of a chain")
+    flags.Float64Var(&options.magnifierZoom, "magnifier-zoom", 8, "zoom factor of the magnified view")
+    flags.StringVar(&options.recordFormat, "record-format", "gif", "format of the R recordings: " + strings.Join(record.FormatNames(), ", "))
+    flags.StringVar(&options.recordOutput, "record-output", "", "file, or directory for png, of the R recordings (default fourier-<time>)")
+    flags.Float64Var(&options.recordFPS, "record-fps", 30, "frame rate of the R recordings")
+    flags.StringVar(&options.replay, "replay", "", "replay an input session recorded with -record-input")
+    flags.BoolVar(&options.replayExit, "replay-exit", false, "quit when the -replay session ends instead of going back to live input")
+    flags.StringVar(&options.recordInput, "record-input", "", "save every tick of input to this file for -replay")
+    flags.StringVar(&options.playlist, "playlist", "", "JSON playlist to play unattended, looping, as a kiosk")
+    flags.StringVar(&options.morph, "morph", "", "morph between two point files, given as from.txt,to.txt")
+    flags.StringVar(&morphMode, "morph-mode", "linear", "how morphing blends the terms: linear, or polar for magnitude and phase apart")
+    flags.Float64Var(&options.morphSeconds, "morph-seconds", 6, "seconds to morph from one drawing to the other")
+    flags.IntVar(&options.morphPoints, "morph-points", 256, "number of points both drawings are resampled to before morphing")
+    flags.Parse(args)
+
+    state, ok := startStates[strings.ToLower(start)]
+    if (!ok) {
+        fmt.Fprintf(os.Stderr, "invalid -start value %q\n", start)
+        flags.Usage()
+        os.Exit(2)
+    }
+    options.startState = state
+    trailStyle, ok := trailStyleByName(strings.ToLower(trail))
+    if (!ok) {
+        fmt.Fprintf(os.Stderr, "invalid -trail value %q\n", trail)
+        flags.Usage()
+        os.Exit(2)
+    }
+    options.trailStyle = trailStyle
+    if err := checkFilter(); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        flags.Usage()
+        os.Exit(2)
+    }
+    if err := checkNormalize(); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        flags.Usage()
+        os.Exit(2)
+    }
+    if err := checkWindow(); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        flags.Usage()
+        os.Exit(2)
+    }
+    options.morphMode, ok = morphModeByName(strings.ToLower(morphMode))
+    if (!ok) {
+        fmt.Fprintf(os.Stderr, "invalid -morph-mode value %q\n", morphMode)
+        flags.Usage()
+        os.Exit(2)
+    }
+    if _, ok := record.Formats[options.recordFormat]; !ok {
+        fmt.Fprintf(os.Stderr, "invalid -record-format value %q\n", options.recordFormat)
+        flags.Usage()
+        os.Exit(2)
+    }
+    if (options.width <= 0 || options.height <= 0 || options.speed <= 0 || options.trailLength <= 0 || options.magnifierZoom <= 0 || options.recordFPS <= 0 || options.morphSeconds <= 0 || options.morphPoints < 2 || options.maxEpicycles < 0) {
+        fmt.Fprintf(os.Stderr, "-width, -height, -speed, -trail-length, -magnifier-zoom, -record-fps and -morph-seconds must be positive, -morph-points at least 2, -max-epicycles not negative\n")
+        os.Exit(2)
+    }
+
+    return options
+}
+
+type spectrumTerm struct {
+    Axis        string  `json:"axis"`
+    Freq        int     `json:"freq"`
+    Re          float64 `json:"re"`
+    Im          float64 `json:"im"`
+    Amplitude   float64 `json:"amplitude"`
+    Phase       float64 `json:"phase"`
+}
+
+// runCompute implements the non-interactive "compute" subcommand: it reads a point file
+// and prints or writes its spectrum without opening a window.
+func runCompute(args []string) error {
+    flags := flag.NewFlagSet("compute", flag.ExitOnError)
+    flags.Usage = func() {
+        fmt.Fprintf(flags.Output(), "Usage:\n  fourier-drawing compute [flags] <points file>\n\nFlags:\n")
+        flags.PrintDefaults()
+    }
+    output := flags.String("o", "", "write the spectrum to this file instead of stdout")
+    format := flags.String("format", "text", "output format: text, csv or json")
+    width := flags.Int("width", 1920, "canvas width, the points are centred on it before the transform")
+    height := flags.Int("height", 1080, "canvas height, the points are centred on it before the transform")
+    maxEpicycles := flags.Int("max-epicycles", 0, "number of terms per axis to output, largest first (0 outputs all)")
+    byTime := flags.Bool("by-time", false, "resample timed drawings at even time steps before the transform")
+    var filter SpectrumFilter
+    checkFilter := addFilterFlags(flags, &filter)
+    var normalization Normalization
+    checkNormalize := addNormalizeFlags(flags, &normalization)
+    var window SpectrumWindow
+    checkWindow := addWindowFlags(flags, &window)
+    flags.Parse(args)
+
+    for _, check := range []func() error{checkFilter, checkNormalize, checkWindow} {
+        if err := check(); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            flags.Usage()
+            os.Exit(2)
+        }
+    }
+    // Checked here, so a bad -format fails before -o creates its file.
+    if (*format != "text" && *format != "csv" && *format != "json") {
+        fmt.Fprintf(os.Stderr, "invalid -format value %q\n", *format)
+        flags.Usage()
+        os.Exit(2)
+    }
+    if (flags.NArg() != 1) {
+        flags.Usage()
+        os.Exit(2)
+    }
+
+    points, err := readPointsFromFile(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+    if (len(points) == 0) {
+        return fmt.Errorf("%s contains no points", flags.Arg(0))
+    }
+
+    points = normalizePoints(points, &normalization, *width, *height)
+    model := computeModel(points, *width, *height, *maxEpicycles, *byTime, &filter, &window)
+
+    if (*output == "") {
+        return writeSpectrum(os.Stdout, *format, model.X.Terms, model.Y.Terms)
+    }
+    file, err := os.Create(*output)
+    if err != nil {
+        return err
+    }
+    if err := writeSpectrum(file, *format, model.X.Terms, model.Y.Terms); err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
+
+func writeSpectrum(w io.Writer, format string, fourierX, fourierY []fourier.FourierElement) error {
+    var terms []spectrumTerm
+    for _, axis := range []struct{ name string; seq []fourier.FourierElement }{{"x", fourierX}, {"y", fourierY}} {
+        N := float64(len(axis.seq))
+        for _, element := range axis.seq {
+            if (element.Val == 0) {
+                continue
+            }
+            terms = append(terms, spectrumTerm{
+                Axis:       axis.name,
+                Freq:       element.Freq,
+                Re:         real(element.Val),
+                Im:         imag(element.Val),
+                Amplitude:  cmplx.Abs(element.Val)/N,
+                Phase:      cmplx.Phase(element.Val),
+            })
+        }
+    }
+
+    switch format {
+    case "json":
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(struct{
+            N       int             `json:"n"`
+            Terms   []spectrumTerm  `json:"terms"`
+        }{len(fourierX), terms})
+    case "csv":
+        if _, err := fmt.Fprintln(w, "axis,freq,re,im,amplitude,phase"); err != nil {
+            return err
+        }
+        for _, t := range terms {
+            if _, err := fmt.Fprintf(w, "%s,%d,%f,%f,%f,%f\n", t.Axis, t.Freq, t.Re, t.Im, t.Amplitude, t.Phase); err != nil {
+                return err
+            }
+        }
+    case "text":
+        if _, err := fmt.Fprintf(w, "N = %d\n", len(fourierX)); err != nil {
+            return err
+        }
+        for _, t := range terms {
+            if _, err := fmt.Fprintf(w, "%s  freq %6d  amplitude %12.6f  phase %9.4f rad (%7.2f deg)\n", t.Axis, t.Freq, t.Amplitude, t.Phase, t.Phase*180/math.Pi); err != nil {
+                return err
+            }
+        }
+    default:
+        return fmt.Errorf("unknown format %q", format)
+    }
+
+    return nil
+}
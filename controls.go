@@ -0,0 +1,521 @@
+package main
+
+import (
+    "fmt"
+    "path/filepath"
+
+    "github.com/hajimehoshi/ebiten/v2"
+
+    "fourier-drawing/ui"
+)
+
+func screens(states ...GameState) []int {
+    s := make([]int, len(states))
+    for i, state := range states {
+        s[i] = int(state)
+    }
+    return s
+}
+
+// buildWidgets registers every control of the app. Each widget declares the
+// states it is shown in, so Update and Draw only go through the registry.
+func (g *Game) buildWidgets() {
+    g.widgets = ui.NewRegistry()
+
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "start", Bounds: ui.Rect{X: 785, Y: 470, W: 350, H: 110}, Screens: screens(START)},
+        Label:      "START",
+        TextSize:   48,
+        OnClick:    func() { g.setState(DRAWING) },
+    })
+
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "clear", Bounds: ui.Rect{X: 1700, Y: 20, W: 200, H: 60}, Screens: screens(DRAWING)},
+        Label:      "Clear",
+        OnClick:    func() {
+            g.points = make([]Point, 0)
+        },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "edit", Bounds: ui.Rect{X: 1480, Y: 20, W: 200, H: 60}, Screens: screens(DRAWING),
+            DisabledIf: func() bool { return !g.machine.Can(EDITING) }},
+        Label:      "Edit",
+        OnClick:    func() { g.setState(EDITING) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "edit-done", Bounds: ui.Rect{X: 1700, Y: 20, W: 200, H: 60}, Screens: screens(EDITING)},
+        Label:      "Done",
+        OnClick:    func() { g.setState(DRAWING) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "edit-delete", Bounds: ui.Rect{X: 1480, Y: 20, W: 200, H: 60}, Screens: screens(EDITING),
+            DisabledIf: func() bool { return !g.editor.hasSelection() }},
+        Label:      "Delete",
+        OnClick:    g.deleteSelectedPoints,
+    })
+    normalize := &ui.Panel{
+        Base:       ui.Base{ID: "normalize", Bounds: ui.Rect{X: 1540, Y: 100, W: 360}, Screens: screens(EDITING)},
+        Title:      "Normalize on load and run",
+        Padding:    10,
+        Spacing:    8,
+    }
+    normalize.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "center", Bounds: ui.Rect{H: 36}},
+        Label:      "Centre on the centroid",
+        Value:      &g.options.normalization.center,
+    })
+    normalize.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "fit", Bounds: ui.Rect{H: 36}},
+        Label:      "Fit to the canvas",
+        Value:      &g.options.normalization.fit,
+    })
+    normalize.Add(&ui.Slider{
+        Base:       ui.Base{ID: "fit-padding", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return !g.options.normalization.fit }},
+        Label:      "Padding",
+        Min:        0,
+        Max:        300,
+        Step:       10,
+        Value:      &g.options.normalization.padding,
+        Format:     "%.0f px",
+    })
+    normalize.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "align", Bounds: ui.Rect{H: 36}},
+        Label:      "Principal axis horizontal",
+        Value:      &g.options.normalization.align,
+    })
+    normalize.Add(&ui.Button{
+        Base:       ui.Base{ID: "start-point", Bounds: ui.Rect{H: 36}},
+        Label:      "Start: " + startPointNames[g.options.normalization.start],
+        OnClick:    func() { g.setStartPoint(g.options.normalization.start+1) },
+    })
+    normalize.Add(&ui.Button{
+        Base:       ui.Base{ID: "normalize-now", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return !g.options.normalization.active() }},
+        Label:      "Normalize now",
+        OnClick:    func() {
+            g.normalizeDrawing()
+            g.editor.reset(len(g.points))
+        },
+    })
+    normalize.Add(&ui.Button{
+        Base:       ui.Base{ID: "start-here", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return g.editor.singleSelection() <= 0 }},
+        Label:      "Start at the selected point",
+        OnClick:    g.startAtSelection,
+    })
+    g.widgets.Add(normalize)
+    g.widgets.Add(&ui.Label{
+        Base:       ui.Base{ID: "edit-hint", Bounds: ui.Rect{X: 20, Y: 30}, Screens: screens(EDITING)},
+        Text:       "Drag points to move them, drag elsewhere to select a box, Shift adds to the selection - H for the keys",
+        Size:       20,
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "guess", Bounds: ui.Rect{X: 1040, Y: 20, W: 200, H: 60}, Screens: screens(DRAWING),
+            DisabledIf: func() bool { return len(g.points) < 3 }},
+        Label:      "Guess (" + g.keymap.KeyNames(GUESS_ACTION) + ")",
+        OnClick:    g.startGuess,
+    })
+    guess := &ui.Panel{
+        Base:       ui.Base{ID: "guess-panel", Bounds: ui.Rect{X: 760, Y: 100, W: 400}, Screens: screens(DRAWING), Hidden: !g.guess.open},
+        Title:      "Guess the drawing",
+        Padding:    10,
+        Spacing:    8,
+    }
+    guess.Add(&ui.Label{
+        Base:       ui.Base{ID: "guess-label", Bounds: ui.Rect{H: 24}},
+        TextFunc:   g.guessLabel,
+    })
+    guess.Add(&ui.Button{
+        Base:       ui.Base{ID: "guess-thumbnail", Bounds: ui.Rect{H: THUMBNAIL_HEIGHT+50},
+            DisabledIf: func() bool { return !g.guessPending() }},
+    })
+    guess.Add(&ui.Button{
+        Base:       ui.Base{ID: "guess-yes", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return !g.guessPending() }},
+        Label:      "Yes",
+        OnClick:    func() { g.answerGuess(true) },
+    })
+    guess.Add(&ui.Button{
+        Base:       ui.Base{ID: "guess-no", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return !g.guessPending() }},
+        Label:      "No",
+        OnClick:    func() { g.answerGuess(false) },
+    })
+    guess.Add(&ui.Label{
+        Base:       ui.Base{ID: "guess-score", Bounds: ui.Rect{H: 24}},
+        TextFunc:   g.guessScore,
+    })
+    guess.Add(&ui.Button{
+        Base:       ui.Base{ID: "guess-close", Bounds: ui.Rect{H: 36}},
+        Label:      "Close",
+        OnClick:    func() { g.setGuessOpen(false) },
+    })
+    g.widgets.Add(guess)
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "morph", Bounds: ui.Rect{X: 1260, Y: 20, W: 200, H: 60}, Screens: screens(DRAWING),
+            DisabledIf: func() bool { return len(g.points) < 2 }},
+        Label:      "Morph to...",
+        OnClick:    func() {
+            g.openFileBrowser(OPEN_FILE, pointFileFilters, func (g *Game, filePath string) error {
+                points, err := readPointsFromFile(filePath)
+                if err != nil {
+                    return err
+                }
+                morph, err := newMorph("drawing", g.points, filepath.Base(filePath), points, g.options.morphPoints, g.windowSize.width, g.windowSize.height, g.options.morphMode, g.options.morphSeconds)
+                if err != nil {
+                    return fmt.Errorf("%s: %w", filePath, err)
+                }
+                g.morph = morph
+                g.setState(MORPHING)
+                return nil
+            })
+        },
+    })
+    g.widgets.Add(&ui.Label{
+        Base:       ui.Base{ID: "morph-label", Bounds: ui.Rect{X: 760, Y: 20}, Screens: screens(MORPHING)},
+        TextFunc:   g.morphLabel,
+        Size:       24,
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "morph-mode", Bounds: ui.Rect{X: 1660, Y: 20, W: 240, H: 60}, Screens: screens(MORPHING)},
+        Label:      "Blend: " + morphModeNames[g.options.morphMode],
+        OnClick:    func() { g.setMorphMode(g.options.morphMode+1) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "morph-back", Bounds: ui.Rect{X: 20, Y: 1000, W: 200, H: 60}, Screens: screens(MORPHING)},
+        Label:      "Back",
+        OnClick:    func() { g.setState(DRAWING) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "save", Bounds: ui.Rect{X: 20, Y: 930, W: 200, H: 60}, Screens: screens(DRAWING),
+            DisabledIf: func() bool { return len(g.points) == 0 }},
+        Label:      "Save",
+        OnClick:    func() {
+            g.openFileBrowser(SAVE_FILE, pointFileFilters, func (g *Game, filePath string) error {
+                return writePointsToFile(filePath, g.points)
+            })
+        },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "load", Bounds: ui.Rect{X: 20, Y: 1000, W: 200, H: 60}, Screens: screens(DRAWING)},
+        Label:      "Load",
+        OnClick:    func() {
+            g.openFileBrowser(OPEN_FILE, pointFileFilters, func (g *Game, filePath string) error {
+                points, err := readPointsFromFile(filePath)
+                if err != nil {
+                    return err
+                }
+                if len(points) == 0 {
+                    return fmt.Errorf("%s contains no points", filePath)
+                }
+                g.points = points
+                g.normalizeDrawing()
+                return nil
+            })
+        },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "gallery", Bounds: ui.Rect{X: 240, Y: 1000, W: 200, H: 60}, Screens: screens(DRAWING)},
+        Label:      "Gallery",
+        OnClick:    func() { g.setState(GALLERY) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "fourier", Bounds: ui.Rect{X: 1650, Y: 980, W: 250, H: 80}, Screens: screens(DRAWING),
+            DisabledIf: func() bool { return !g.machine.Can(REVEALING) }},
+        Label:      "Fourier",
+        TextSize:   32,
+        OnClick:    func() { g.setState(REVEALING) },
+    })
+
+    maxEpicycles := float64(g.options.maxEpicycles)
+    controls := &ui.Panel{
+        Base:       ui.Base{ID: "controls", Bounds: ui.Rect{X: 20, Y: 20, W: 360}, Screens: screens(DRAWING, REVEALING, FOURIER, MORPHING)},
+        Padding:    10,
+        Spacing:    8,
+    }
+    controls.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "toggle-dots", Bounds: ui.Rect{H: 36}},
+        Label:      "Points (" + g.keymap.KeyNames(TOGGLE_DOTS_ACTION) + ")",
+        Value:      &g.toggleDots,
+    })
+    controls.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "toggle-epicycles", Bounds: ui.Rect{H: 36}},
+        Label:      "Epicycles (" + g.keymap.KeyNames(TOGGLE_EPICYCLES_ACTION) + ")",
+        Value:      &g.toggleEpicycles,
+    })
+    controls.Add(&ui.Slider{
+        Base:       ui.Base{ID: "speed", Bounds: ui.Rect{H: 56}},
+        Label:      "Speed",
+        Min:        0.25,
+        Max:        8,
+        Step:       0.25,
+        Value:      &g.options.speed,
+        Format:     "%.2fx",
+    })
+    controls.Add(&ui.Slider{
+        Base:       ui.Base{ID: "max-epicycles", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return g.state != DRAWING }},
+        Label:      "Epicycles kept (0 = all)",
+        Min:        0,
+        Max:        500,
+        Step:       1,
+        Value:      &maxEpicycles,
+        Format:     "%.0f",
+        OnChange:   func(value float64) { g.options.maxEpicycles = int(value) },
+    })
+    controls.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "by-time", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return g.state != DRAWING || !hasTiming(g.points) }},
+        Label:      "Drawing rhythm",
+        Value:      &g.options.byTime,
+    })
+    controls.Add(&ui.Button{
+        Base:       ui.Base{ID: "theme", Bounds: ui.Rect{H: 36}},
+        Label:      "Theme: " + g.theme.Name,
+        OnClick:    func() { g.setTheme(g.themeIndex+1) },
+    })
+    controls.Add(&ui.Button{
+        Base:       ui.Base{ID: "trail", Bounds: ui.Rect{H: 36}},
+        Label:      "Trail: " + trailStyleNames[g.trailStyle],
+        OnClick:    func() { g.setTrailStyle(g.trailStyle+1) },
+    })
+    controls.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "follow-tip", Bounds: ui.Rect{H: 36}},
+        Label:      "Follow tip (" + g.keymap.KeyNames(FOLLOW_TIP_ACTION) + ")",
+        Value:      &g.camera.follow,
+    })
+    controls.Add(&ui.Button{
+        Base:       ui.Base{ID: "reset-camera", Bounds: ui.Rect{H: 36}},
+        Label:      "Reset view (" + g.keymap.KeyNames(RESET_CAMERA_ACTION) + ")",
+        OnClick:    func() {
+            g.camera.Reset()
+            g.camera.follow = false
+        },
+    })
+    g.widgets.Add(controls)
+
+    minRadius := g.epicycleStyle.minRadius
+    epicycles := &ui.Panel{
+        Base:       ui.Base{ID: "epicycle-style", Bounds: ui.Rect{X: 1540, Y: 100, W: 360}, Screens: screens(FOURIER)},
+        Title:      "Epicycles",
+        Padding:    10,
+        Spacing:    8,
+    }
+    epicycles.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "color-by-frequency", Bounds: ui.Rect{H: 36}},
+        Label:      "Color by frequency",
+        Value:      &g.epicycleStyle.colorByFrequency,
+    })
+    epicycles.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "arrows", Bounds: ui.Rect{H: 36}},
+        Label:      "Arrowheads",
+        Value:      &g.epicycleStyle.arrows,
+    })
+    epicycles.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "filled-discs", Bounds: ui.Rect{H: 36}},
+        Label:      "Filled discs",
+        Value:      &g.epicycleStyle.filledDiscs,
+    })
+    epicycles.Add(&ui.Slider{
+        Base:       ui.Base{ID: "min-radius", Bounds: ui.Rect{H: 56}},
+        Label:      "Hide below",
+        Min:        0,
+        Max:        20,
+        Step:       0.5,
+        Value:      &minRadius,
+        Format:     "%.1f px",
+        OnChange:   func(value float64) { g.epicycleStyle.minRadius = value },
+    })
+    epicycles.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "magnifier", Bounds: ui.Rect{H: 36}},
+        Label:      "Magnifier (" + g.keymap.KeyNames(TOGGLE_MAGNIFIER_ACTION) + ")",
+        Value:      &g.magnifier.enabled,
+    })
+    epicycles.Add(&ui.Button{
+        Base:       ui.Base{ID: "magnifier-target", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return !g.magnifier.enabled }},
+        Label:      "Magnify: " + magnifierTargetNames[g.magnifier.target],
+        OnClick:    func() { g.setMagnifierTarget(g.magnifier.target+1) },
+    })
+    epicycles.Add(&ui.Slider{
+        Base:       ui.Base{ID: "magnifier-zoom", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return !g.magnifier.enabled }},
+        Label:      "Magnification",
+        Min:        2,
+        Max:        100,
+        Step:       1,
+        Value:      &g.magnifier.zoom,
+        Format:     "%.0fx",
+    })
+    epicycles.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "spectrum-editor", Bounds: ui.Rect{H: 36}},
+        Label:      "Spectrum editor (" + g.keymap.KeyNames(SPECTRUM_ACTION) + ")",
+        Value:      &g.spectrum.open,
+        OnChange:   g.setSpectrumOpen,
+    })
+    g.widgets.Add(epicycles)
+
+    cutoff, cutoffHigh, order := g.options.filter.cutoff, g.options.filter.cutoffHigh, float64(g.options.filter.order)
+    filter := &ui.Panel{
+        Base:       ui.Base{ID: "filter", Bounds: ui.Rect{X: 1540, Y: 100, W: 360}, Screens: screens(DRAWING)},
+        Title:      "Filter",
+        Padding:    10,
+        Spacing:    8,
+    }
+    filter.Add(&ui.Button{
+        Base:       ui.Base{ID: "filter-kind", Bounds: ui.Rect{H: 36}},
+        Label:      "Filter: " + filterKindNames[g.options.filter.kind],
+        OnClick:    func() { g.setFilterKind(g.options.filter.kind+1) },
+    })
+    filter.Add(&ui.Button{
+        Base:       ui.Base{ID: "filter-shape", Bounds: ui.Rect{H: 36},
+            DisabledIf: func() bool { return !g.options.filter.active() || g.options.filter.kind == NOTCH_FILTER }},
+        Label:      "Shape: " + filterShapeNames[g.options.filter.shape],
+        OnClick:    func() { g.setFilterShape(g.options.filter.shape+1) },
+    })
+    filter.Add(&ui.Slider{
+        Base:       ui.Base{ID: "cutoff", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return !g.options.filter.active() || g.options.filter.kind == NOTCH_FILTER }},
+        Label:      "Cutoff",
+        Min:        1,
+        Max:        200,
+        Step:       1,
+        Value:      &cutoff,
+        Format:     "%.0f",
+        OnChange:   func(value float64) {
+            g.options.filter.cutoff = value
+            if (value >= g.options.filter.cutoffHigh) {
+                cutoffHigh = value+1
+                g.options.filter.cutoffHigh = cutoffHigh
+            }
+        },
+    })
+    filter.Add(&ui.Slider{
+        Base:       ui.Base{ID: "cutoff-high", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return g.options.filter.kind != BAND_PASS_FILTER }},
+        Label:      "Band top",
+        Min:        2,
+        Max:        201,
+        Step:       1,
+        Value:      &cutoffHigh,
+        Format:     "%.0f",
+        OnChange:   func(value float64) {
+            g.options.filter.cutoffHigh = value
+            if (value <= g.options.filter.cutoff) {
+                cutoff = value-1
+                g.options.filter.cutoff = cutoff
+            }
+        },
+    })
+    filter.Add(&ui.Slider{
+        Base:       ui.Base{ID: "filter-order", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return !g.options.filter.active() || g.options.filter.shape != BUTTERWORTH_FILTER }},
+        Label:      "Butterworth order",
+        Min:        1,
+        Max:        8,
+        Step:       1,
+        Value:      &order,
+        Format:     "%.0f",
+        OnChange:   func(value float64) { g.options.filter.order = int(value) },
+    })
+    filter.Add(&ui.Toggle{
+        Base:       ui.Base{ID: "leakage-view", Bounds: ui.Rect{H: 36}},
+        Label:      "Leakage view (" + g.keymap.KeyNames(LEAKAGE_ACTION) + ")",
+        Value:      &g.leakage.open,
+        OnChange:   g.setLeakageOpen,
+    })
+    g.widgets.Add(filter)
+
+    leakage := &ui.Panel{
+        Base:       ui.Base{ID: "leakage", Bounds: ui.Rect{X: 1540, Y: 500, W: 360}, Screens: screens(DRAWING), Hidden: !g.leakage.open},
+        Title:      "Spectral leakage",
+        Padding:    10,
+        Spacing:    8,
+    }
+    leakage.Add(&ui.Button{
+        Base:       ui.Base{ID: "window-kind", Bounds: ui.Rect{H: 36}},
+        Label:      "Window: " + windowKindNames[g.options.window.kind],
+        OnClick:    func() { g.setWindowKind(g.options.window.kind+1) },
+    })
+    leakage.Add(&ui.Slider{
+        Base:       ui.Base{ID: "tukey-alpha", Bounds: ui.Rect{H: 56},
+            DisabledIf: func() bool { return g.options.window.kind != TUKEY_WINDOW }},
+        Label:      "Tukey taper",
+        Min:        0,
+        Max:        1,
+        Step:       0.05,
+        Value:      &g.options.window.tukeyAlpha,
+        Format:     "%.2f",
+    })
+    leakage.Add(&LeakagePlot{
+        Base:       ui.Base{ID: "leakage-plot", Bounds: ui.Rect{H: 240}},
+        g:          g,
+    })
+    g.widgets.Add(leakage)
+
+    spectrum := &ui.Panel{
+        Base:       ui.Base{ID: "spectrum", Bounds: ui.Rect{X: 20, Y: 500, W: 560}, Screens: screens(FOURIER), Hidden: !g.spectrum.open},
+        Title:      "Spectrum - drag a bar or a phase, click to mute or solo",
+        Padding:    10,
+        Spacing:    8,
+    }
+    spectrum.Add(&ui.Button{
+        Base:       ui.Base{ID: "spectrum-reset", Bounds: ui.Rect{H: 36}},
+        Label:      "Undo every edit",
+        OnClick:    g.resetSpectrum,
+    })
+    spectrum.Add(&SpectrumList{
+        Base:       ui.Base{ID: "spectrum-list", Bounds: ui.Rect{H: (SPECTRUM_ROWS+1)*SPECTRUM_ROW_HEIGHT}},
+        g:          g,
+    })
+    g.widgets.Add(spectrum)
+
+    g.widgets.Add(&ui.Label{
+        Base:       ui.Base{ID: "reveal-hint", Bounds: ui.Rect{X: 900, Y: 20}, Screens: screens(REVEALING)},
+        TextFunc:   func() string { return "Press " + g.keymap.KeyNames(SKIP_REVEAL_ACTION) + " to skip, " + g.keymap.KeyNames(HELP_ACTION) + " for help" },
+    })
+
+    g.widgets.Add(&ui.Label{
+        Base:       ui.Base{ID: "gallery-title", Bounds: ui.Rect{X: 60, Y: 80}, Screens: screens(GALLERY)},
+        TextFunc:   func() string {
+            return fmt.Sprintf("Gallery: %d drawings - click one to play it, scroll for more, * marks your own", len(g.gallery))
+        },
+        Size:       24,
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "gallery-back", Bounds: ui.Rect{X: 20, Y: 1000, W: 200, H: 60}, Screens: screens(GALLERY)},
+        Label:      "Back",
+        OnClick:    func() { g.setState(DRAWING) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "gallery-add", Bounds: ui.Rect{X: 1580, Y: 1000, W: 320, H: 60}, Screens: screens(GALLERY),
+            DisabledIf: func() bool { return len(g.points) == 0 }},
+        Label:      "Add current drawing",
+        OnClick:    func() {
+            if err := addToUserGallery(g.points); err != nil {
+                fmt.Printf("Unable to add drawing to gallery.\n")
+            }
+            g.refreshGallery()
+        },
+    })
+
+    g.buildFileBrowserWidgets()
+}
+
+// readUIInput collects the pointer and keyboard state for the widget registry.
+func (g *Game) readUIInput() ui.Input {
+    shift := g.input.KeyPressed(ebiten.KeyShiftLeft) || g.input.KeyPressed(ebiten.KeyShiftRight)
+    return ui.Input{
+        X:              g.input.pointer.X,
+        Y:              g.input.pointer.Y,
+        Pressed:        g.input.pointer.Pressed(),
+        JustPressed:    g.input.PointerJustPressed(),
+        JustReleased:   g.input.PointerJustReleased(),
+        FocusNext:      g.input.KeyJustPressed(ebiten.KeyTab) && !shift,
+        FocusPrevious:  g.input.KeyJustPressed(ebiten.KeyTab) && shift,
+        Activate:       g.input.KeyJustPressed(ebiten.KeyEnter),
+        Increase:       g.input.RepeatingKeyPressed(ebiten.KeyRight),
+        Decrease:       g.input.RepeatingKeyPressed(ebiten.KeyLeft),
+    }
+}
@@ -0,0 +1,138 @@
+// Package descriptors compares shapes by their Fourier descriptors: the complex DFT
+// of a closed contour, normalized so the same shape matches itself whatever its
+// position, size, rotation and starting point.
+package descriptors
+
+import (
+    "errors"
+    "math"
+    "math/cmplx"
+
+    "fourier-drawing/fourier"
+)
+
+const (
+    // DEFAULT_SAMPLES is the number of points contours are resampled to.
+    DEFAULT_SAMPLES = 128
+    // DEFAULT_TERMS is the number of frequencies kept in each direction.
+    DEFAULT_TERMS = 16
+)
+
+var ErrDegenerate = errors.New("the contour has no extent")
+
+// Descriptors are the normalized terms of a contour, for the frequencies -K to -1
+// then 1 to K. The constant term, which only places the contour, is left out.
+type Descriptors struct {
+    Terms   []complex128
+}
+
+// Resample returns n points evenly spaced along contour, closed from its last point
+// back to its first, so the spacing of the original points does not matter.
+func Resample(contour []complex128, n int) ([]complex128) {
+    lengths := make([]float64, len(contour)+1)
+    for i:=1; i<=len(contour); i++ {
+        lengths[i] = lengths[i-1]+cmplx.Abs(contour[i%len(contour)]-contour[i-1])
+    }
+    total := lengths[len(contour)]
+    out := make([]complex128, n)
+    j := 1
+    for i := range out {
+        at := total*float64(i)/float64(n)
+        for j < len(contour) && lengths[j] < at {
+            j++
+        }
+        a, b := contour[j-1], contour[j%len(contour)]
+        u := 0.0
+        if segment := lengths[j]-lengths[j-1]; segment > 0 {
+            u = (at-lengths[j-1])/segment
+        }
+        out[i] = a+complex(u, 0)*(b-a)
+    }
+    return out
+}
+
+// Compute returns the descriptors of contour with terms frequencies each way. The
+// contour is resampled to samples points, which must be more than 2*terms. Traced
+// the other way round, a contour gets the same descriptors; mirrored, it gets their
+// conjugates, which Distance also matches. Points along a line are a shape too: the
+// segment traced there and back.
+func Compute(contour []complex128, samples, terms int) (Descriptors, error) {
+    if (len(contour) < 3 || samples <= 2*terms) {
+        return Descriptors{}, ErrDegenerate
+    }
+    Z := fourier.ComplexDFT(Resample(contour, samples))
+    term := func(k int) complex128 {
+        return Z[(k+samples)%samples].Val
+    }
+
+    // The larger term turning once fixes the direction. The size is that of all the
+    // terms but the constant one, which a single small term could not stand for.
+    sign := 1
+    if (cmplx.Abs(term(-1)) > cmplx.Abs(term(1))) {
+        sign = -1
+    }
+    size := 0.0
+    for _, element := range Z[1:] {
+        size += sq(cmplx.Abs(element.Val))
+    }
+    size = math.Sqrt(size)
+    if (size < 1e-9*float64(samples)) {
+        return Descriptors{}, ErrDegenerate
+    }
+
+    // Turning the contour by theta adds theta to every phase; starting it a fraction s
+    // further on adds 2*pi*k*s to the phase of term k. The phases of the terms at 1 and
+    // -1 give both, and taking them out leaves the shape alone.
+    phase1, phase2 := cmplx.Phase(term(sign)), cmplx.Phase(term(-sign))
+    if (term(-sign) == 0) {
+        phase2 = phase1
+    }
+    theta, shift := (phase1+phase2)/2, (phase1-phase2)/2
+
+    d := Descriptors{Terms: make([]complex128, 0, 2*terms)}
+    for _, k := range frequencies(terms) {
+        rotation := cmplx.Rect(1/size, -theta-float64(k)*shift)
+        d.Terms = append(d.Terms, term(sign*k)*rotation)
+    }
+    return d, nil
+}
+
+func frequencies(terms int) ([]int) {
+    ks := make([]int, 0, 2*terms)
+    for k:=-terms; k<=terms; k++ {
+        if (k != 0) {
+            ks = append(ks, k)
+        }
+    }
+    return ks
+}
+
+// Distance is 0 for the same shape, mirrored or not, and at most 2; a circle and a
+// line are about 0.8 apart. The phases fixed by Compute are only known up to half a
+// turn, which negates the terms at even frequencies, so both ways are tried, and
+// both again against the conjugates of b for its mirror image.
+func Distance(a, b Descriptors) (float64) {
+    terms := min(len(a.Terms), len(b.Terms))/2
+    if (terms == 0) {
+        return math.Inf(1)
+    }
+    var sums [4]float64
+    for i, k := range frequencies(terms) {
+        ai := a.Terms[len(a.Terms)/2-terms+i]
+        bi := b.Terms[len(b.Terms)/2-terms+i]
+        if (k%2 == 0) {
+            sums[1] += sq(cmplx.Abs(ai+bi))
+            sums[3] += sq(cmplx.Abs(ai+cmplx.Conj(bi)))
+        } else {
+            sums[1] += sq(cmplx.Abs(ai-bi))
+            sums[3] += sq(cmplx.Abs(ai-cmplx.Conj(bi)))
+        }
+        sums[0] += sq(cmplx.Abs(ai-bi))
+        sums[2] += sq(cmplx.Abs(ai-cmplx.Conj(bi)))
+    }
+    return math.Sqrt(min(sums[0], sums[1], sums[2], sums[3]))
+}
+
+func sq(x float64) (float64) {
+    return x*x
+}
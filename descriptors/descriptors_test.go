@@ -0,0 +1,116 @@
+package descriptors
+
+import (
+    "errors"
+    "math"
+    "math/cmplx"
+    "testing"
+)
+
+// lShape is an L, which no rotation turns into its mirror image.
+var lShape = []complex128{0, 4, 4+1i, 1+1i, 1+3i, 3i}
+
+func transform(contour []complex128, f func(complex128) complex128) []complex128 {
+    out := make([]complex128, len(contour))
+    for i, z := range contour {
+        out[i] = f(z)
+    }
+    return out
+}
+
+func reversed(contour []complex128) []complex128 {
+    out := make([]complex128, len(contour))
+    for i, z := range contour {
+        out[len(contour)-1-i] = z
+    }
+    return out
+}
+
+func circle(n int) []complex128 {
+    out := make([]complex128, n)
+    for i := range out {
+        out[i] = cmplx.Rect(1, 2*math.Pi*float64(i)/float64(n))
+    }
+    return out
+}
+
+func mustCompute(t *testing.T, contour []complex128) Descriptors {
+    t.Helper()
+    d, err := Compute(contour, DEFAULT_SAMPLES, DEFAULT_TERMS)
+    if err != nil {
+        t.Fatalf("Compute(%v) = %v", contour, err)
+    }
+    return d
+}
+
+func TestInvariance(t *testing.T) {
+    tests := []struct {
+        name    string
+        contour []complex128
+    }{
+        {"rotated", transform(lShape, func(z complex128) complex128 { return z*cmplx.Rect(1, 1.1) })},
+        {"scaled", transform(lShape, func(z complex128) complex128 { return z*7.5 })},
+        {"shifted", transform(lShape, func(z complex128) complex128 { return z+(120-45i) })},
+        {"restarted", append(append([]complex128(nil), lShape[2:]...), lShape[:2]...)},
+        {"reversed", reversed(lShape)},
+        {"mirrored", transform(lShape, cmplx.Conj)},
+        {"all at once", transform(lShape, func(z complex128) complex128 { return cmplx.Conj(z)*cmplx.Rect(3, -2)+50 })},
+    }
+
+    shape := mustCompute(t, lShape)
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            if d := Distance(shape, mustCompute(t, test.contour)); d > 0.02 {
+                t.Errorf("distance to the %s L = %.3f, want about 0", test.name, d)
+            }
+        })
+    }
+}
+
+func TestDistinctShapes(t *testing.T) {
+    line := mustCompute(t, []complex128{0, 1, 0})
+    round := mustCompute(t, circle(40))
+    if d := Distance(round, line); math.Abs(d-0.8) > 0.1 {
+        t.Errorf("distance between a circle and a line = %.3f, want about 0.8", d)
+    }
+    if d := Distance(round, mustCompute(t, lShape)); d < 0.1 {
+        t.Errorf("distance between a circle and an L = %.3f, want them apart", d)
+    }
+    if d := Distance(round, round); d != 0 {
+        t.Errorf("distance of a circle to itself = %v, want 0", d)
+    }
+}
+
+func TestDegenerate(t *testing.T) {
+    tests := []struct {
+        name    string
+        contour []complex128
+        samples int
+        err     bool
+    }{
+        {"empty", nil, DEFAULT_SAMPLES, true},
+        {"two points", []complex128{0, 1}, DEFAULT_SAMPLES, true},
+        {"one point repeated", []complex128{2+2i, 2+2i, 2+2i, 2+2i}, DEFAULT_SAMPLES, true},
+        {"too few samples", lShape, 2*DEFAULT_TERMS, true},
+        {"collinear", []complex128{0, 1+1i, 3+3i}, DEFAULT_SAMPLES, false},
+        {"triangle", []complex128{0, 1, 1i}, DEFAULT_SAMPLES, false},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            _, err := Compute(test.contour, test.samples, DEFAULT_TERMS)
+            if (test.err && !errors.Is(err, ErrDegenerate)) {
+                t.Errorf("Compute = %v, want %v", err, ErrDegenerate)
+            }
+            if (!test.err && err != nil) {
+                t.Errorf("Compute = %v, want no error", err)
+            }
+        })
+    }
+}
+
+func TestCollinearIsALine(t *testing.T) {
+    segment := mustCompute(t, []complex128{0, 3+3i, 0})
+    if d := Distance(mustCompute(t, []complex128{0, 1+1i, 3+3i}), segment); d > 0.02 {
+        t.Errorf("distance between collinear points and their segment = %.3f, want about 0", d)
+    }
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+    "math"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+    // EDIT_HANDLE_RADIUS is how close to a point, in screen pixels, a press grabs it.
+    EDIT_HANDLE_RADIUS = 8.0
+    EDIT_ROTATE_STEP = math.Pi/36
+    EDIT_SCALE_STEP = 1.05
+)
+
+// Editor is the EDITING state: the points of the drawing become handles that can be
+// selected, dragged, inserted and deleted, and a selection can be rotated and scaled
+// around its centre.
+type Editor struct {
+    // selected runs parallel to g.points.
+    selected        []bool
+    // dragging moves the selection with the pointer; x, y is the last board position.
+    dragging        bool
+    x, y            float64
+    // boxing selects the points inside the screen rectangle from box0 to box1.
+    boxing          bool
+    box0, box1      Pointer
+}
+
+func (e *Editor) reset(n int) {
+    *e = Editor{selected: make([]bool, n)}
+}
+
+func (e *Editor) clearSelection() {
+    for i := range e.selected {
+        e.selected[i] = false
+    }
+}
+
+func (e *Editor) hasSelection() bool {
+    for _, s := range e.selected {
+        if s {
+            return true
+        }
+    }
+    return false
+}
+
+// singleSelection returns the index of the selected point when exactly one is
+// selected, -1 otherwise.
+func (e *Editor) singleSelection() int {
+    index := -1
+    for i, s := range e.selected {
+        if s {
+            if index >= 0 {
+                return -1
+            }
+            index = i
+        }
+    }
+    return index
+}
+
+func (e *Editor) boxRect() (x0, y0, x1, y1 float64) {
+    return math.Min(e.box0.X, e.box1.X), math.Min(e.box0.Y, e.box1.Y), math.Max(e.box0.X, e.box1.X), math.Max(e.box0.Y, e.box1.Y)
+}
+
+// pointAt returns the index of the point closest to the screen position (x, y)
+// within EDIT_HANDLE_RADIUS, or -1.
+func (g *Game) pointAt(x, y float64) int {
+    index := -1
+    best := EDIT_HANDLE_RADIUS
+    for i, p := range g.points {
+        sx, sy := g.camera.ToScreen(p.x, p.y)
+        if d := math.Hypot(sx-x, sy-y); d <= best {
+            index, best = i, d
+        }
+    }
+    return index
+}
+
+func (g *Game) pointsChanged() {
+    g.leakage.stale = true
+}
+
+// insertPoint adds the board point (x, y) to the segment closest to it, timing and
+// pressure interpolated from the segment ends, and makes it the only selected point.
+func (g *Game) insertPoint(x, y float64) {
+    e := &g.editor
+    p := Point{x: x, y: y, pressure: 1}
+    index := len(g.points)
+    if (len(g.points) >= 2) {
+        best := math.Inf(1)
+        for i:=1; i<len(g.points); i++ {
+            a, b := g.points[i-1], g.points[i]
+            dx, dy := b.x-a.x, b.y-a.y
+            u := 0.0
+            if length := dx*dx+dy*dy; length > 0 {
+                u = math.Max(0, math.Min(1, ((x-a.x)*dx+(y-a.y)*dy)/length))
+            }
+            if d := math.Hypot(a.x+u*dx-x, a.y+u*dy-y); d < best {
+                best = d
+                index = i
+                p.t = a.t+u*(b.t-a.t)
+                p.pressure = a.pressure+u*(b.pressure-a.pressure)
+            }
+        }
+    } else if (len(g.points) == 1) {
+        p.t, p.pressure = g.points[0].t, g.points[0].pressure
+    }
+
+    g.points = append(g.points[:index], append([]Point{p}, g.points[index:]...)...)
+    e.clearSelection()
+    e.selected = append(e.selected[:index], append([]bool{true}, e.selected[index:]...)...)
+    g.pointsChanged()
+}
+
+func (g *Game) deleteSelectedPoints() {
+    e := &g.editor
+    points := g.points[:0]
+    for i, p := range g.points {
+        if !e.selected[i] {
+            points = append(points, p)
+        }
+    }
+    g.points = points
+    e.reset(len(points))
+    g.pointsChanged()
+}
+
+// transformSelection moves every selected point through f, given the centre of the
+// selection's bounding box.
+func (g *Game) transformSelection(f func(x, y, cx, cy float64) (float64, float64)) {
+    e := &g.editor
+    minX, minY := math.Inf(1), math.Inf(1)
+    maxX, maxY := math.Inf(-1), math.Inf(-1)
+    for i, p := range g.points {
+        if e.selected[i] {
+            minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+            minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+        }
+    }
+    if (minX > maxX) {
+        return
+    }
+    cx, cy := (minX+maxX)/2, (minY+maxY)/2
+    for i := range g.points {
+        if e.selected[i] {
+            g.points[i].x, g.points[i].y = f(g.points[i].x, g.points[i].y, cx, cy)
+        }
+    }
+    g.pointsChanged()
+}
+
+func (g *Game) rotateSelection(angle float64) {
+    sin, cos := math.Sincos(angle)
+    g.transformSelection(func(x, y, cx, cy float64) (float64, float64) {
+        return cx+(x-cx)*cos-(y-cy)*sin, cy+(x-cx)*sin+(y-cy)*cos
+    })
+}
+
+func (g *Game) scaleSelection(factor float64) {
+    g.transformSelection(func(x, y, cx, cy float64) (float64, float64) {
+        return cx+(x-cx)*factor, cy+(y-cy)*factor
+    })
+}
+
+// updateEditor handles the pointer and the editing actions. A press on a point
+// grabs the selection, a press elsewhere starts a box; Shift adds to the selection.
+func (g *Game) updateEditor(pointerCaptured bool) {
+    e := &g.editor
+    pointer := g.input.pointer
+    shift := g.input.KeyPressed(ebiten.KeyShiftLeft) || g.input.KeyPressed(ebiten.KeyShiftRight)
+
+    if (g.keymap.Triggered(SELECT_ALL_ACTION, g.state, &g.input)) {
+        for i := range e.selected {
+            e.selected[i] = true
+        }
+    }
+    if (g.keymap.Triggered(DELETE_POINTS_ACTION, g.state, &g.input)) {
+        g.deleteSelectedPoints()
+    }
+    if (g.keymap.Triggered(INSERT_POINT_ACTION, g.state, &g.input) && !pointerCaptured) {
+        g.insertPoint(g.camera.ToWorld(pointer.X, pointer.Y))
+    }
+    if (g.keymap.Triggered(ROTATE_LEFT_ACTION, g.state, &g.input)) {
+        g.rotateSelection(-EDIT_ROTATE_STEP)
+    }
+    if (g.keymap.Triggered(ROTATE_RIGHT_ACTION, g.state, &g.input)) {
+        g.rotateSelection(EDIT_ROTATE_STEP)
+    }
+    if (g.keymap.Triggered(SCALE_UP_ACTION, g.state, &g.input)) {
+        g.scaleSelection(EDIT_SCALE_STEP)
+    }
+    if (g.keymap.Triggered(SCALE_DOWN_ACTION, g.state, &g.input)) {
+        g.scaleSelection(1/EDIT_SCALE_STEP)
+    }
+
+    // A second finger turns the gesture into a pinch of the camera.
+    if (len(pointer.Contacts) > 1 || g.camera.pinching) {
+        e.dragging, e.boxing = false, false
+        return
+    }
+
+    if (g.input.PointerJustPressed() && !pointerCaptured) {
+        if i := g.pointAt(pointer.X, pointer.Y); i >= 0 {
+            if (shift) {
+                e.selected[i] = !e.selected[i]
+            } else if (!e.selected[i]) {
+                e.clearSelection()
+                e.selected[i] = true
+            }
+            e.dragging = e.selected[i]
+            e.x, e.y = g.camera.ToWorld(pointer.X, pointer.Y)
+        } else {
+            if (!shift) {
+                e.clearSelection()
+            }
+            e.boxing = true
+            e.box0 = Pointer{X: pointer.X, Y: pointer.Y}
+            e.box1 = e.box0
+        }
+    } else if (pointer.Pressed()) {
+        if (e.dragging) {
+            x, y := g.camera.ToWorld(pointer.X, pointer.Y)
+            if (x != e.x || y != e.y) {
+                dx, dy := x-e.x, y-e.y
+                g.transformSelection(func(x, y, _, _ float64) (float64, float64) {
+                    return x+dx, y+dy
+                })
+                e.x, e.y = x, y
+            }
+        }
+        if (e.boxing) {
+            e.box1 = Pointer{X: pointer.X, Y: pointer.Y}
+        }
+    } else if (g.input.PointerJustReleased()) {
+        if (e.boxing) {
+            x0, y0, x1, y1 := e.boxRect()
+            for i, p := range g.points {
+                sx, sy := g.camera.ToScreen(p.x, p.y)
+                if (sx >= x0 && sx <= x1 && sy >= y0 && sy <= y1) {
+                    e.selected[i] = true
+                }
+            }
+        }
+        e.dragging, e.boxing = false, false
+    }
+}
+
+// drawEditor draws the drawing with a handle on every point, the selected ones
+// filled, and the selection box while one is dragged.
+func (g *Game) drawEditor(screen *ebiten.Image) {
+    e := &g.editor
+    g.drawPoints(screen, len(g.points))
+    for i, p := range g.points {
+        x, y := g.camera.ToScreen(p.x, p.y)
+        if (e.selected[i]) {
+            vector.DrawFilledCircle(screen, float32(x), float32(y), 6, g.theme.TipX, true)
+        } else {
+            vector.StrokeCircle(screen, float32(x), float32(y), 5, 1.5, g.theme.Dots, true)
+        }
+    }
+    if (e.boxing) {
+        x0, y0, x1, y1 := e.boxRect()
+        vector.DrawFilledRect(screen, float32(x0), float32(y0), float32(x1-x0), float32(y1-y0), scaleAlpha(g.theme.Guides, 0.2), false)
+        vector.StrokeRect(screen, float32(x0), float32(y0), float32(x1-x0), float32(y1-y0), 1, g.theme.Guides, false)
+    }
+}
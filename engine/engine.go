@@ -0,0 +1,139 @@
+// Package engine is the simulation behind the drawing: from the spectra of the two
+// coordinates and a time t it works out the chains of epicycles and the path they
+// trace. It draws nothing, so the window, the headless renderers and the SVG writer
+// all show the same geometry.
+package engine
+
+import (
+    "math"
+    "math/cmplx"
+
+    "fourier-drawing/fourier"
+)
+
+type Vec struct {
+    X, Y float64
+}
+
+// Epicycle is one term of a chain at a given time: a circle around Center whose
+// radius points from Center to End at Angle, counterclockwise with y growing down.
+type Epicycle struct {
+    Freq    int
+    Radius  float64
+    Angle   float64
+    Center  Vec
+    End     Vec
+}
+
+// Chain is the epicycles of one axis at a given time, largest first when the
+// spectrum is sorted by module. Terms with a zero value are left out.
+type Chain struct {
+    Epicycles   []Epicycle
+    Tip         Vec
+    // MaxRadius is the radius of the largest term, zero terms included.
+    MaxRadius   float64
+    // N is the length of the transform, which the frequencies are relative to.
+    N           int
+}
+
+// Axis is the spectrum of one coordinate and where its chain is anchored.
+type Axis struct {
+    Terms   []fourier.FourierElement
+    Origin  Vec
+    // Phase turns every term; -π/2 makes a chain trace its coordinate vertically.
+    Phase   float64
+}
+
+// Chain returns the epicycles of a at time t, in samples of the transform.
+func (a *Axis) Chain(t float64) Chain {
+    N := len(a.Terms)
+    chain := Chain{N: N, Tip: a.Origin}
+    for _, term := range a.Terms {
+        chain.MaxRadius = math.Max(chain.MaxRadius, cmplx.Abs(term.Val)/float64(N))
+    }
+
+    for _, term := range a.Terms {
+        if (term.Val == 0) {
+            continue
+        }
+        radius := cmplx.Abs(term.Val)/float64(N)
+        angle := 2*math.Pi*t*float64(term.Freq)/float64(N)+cmplx.Phase(term.Val)+a.Phase
+        center := chain.Tip
+        chain.Tip = Vec{center.X+radius*math.Cos(angle), center.Y-radius*math.Sin(angle)}
+        chain.Epicycles = append(chain.Epicycles, Epicycle{
+            Freq:   term.Freq,
+            Radius: radius,
+            Angle:  angle,
+            Center: center,
+            End:    chain.Tip,
+        })
+    }
+    return chain
+}
+
+// Model is a drawing as two chains: X traces the horizontal coordinate with its
+// tip's x and Y the vertical one with its tip's y.
+type Model struct {
+    X, Y    Axis
+    // Center is added back to the reconstructed coordinates, the spectra being
+    // taken around it.
+    Center  Vec
+    // Path is the reconstructed drawing, one point per sample.
+    Path    []Vec
+}
+
+func NewModel(x, y Axis, center Vec) *Model {
+    m := &Model{X: x, Y: y, Center: center}
+    m.Trace()
+    return m
+}
+
+// Trace reconstructs Path from the terms. Call it after changing them.
+func (m *Model) Trace() {
+    xs := fourier.InverseDFT(m.X.Terms)
+    ys := fourier.InverseDFT(m.Y.Terms)
+    m.Path = make([]Vec, min(len(xs), len(ys)))
+    for i := range m.Path {
+        m.Path[i] = Vec{xs[i]+m.Center.X, ys[i]+m.Center.Y}
+    }
+}
+
+// SetTerm sets the value of term i of axis, which is &m.X or &m.Y, and updates Path
+// by the difference, which costs one pass over the samples instead of a full Trace.
+func (m *Model) SetTerm(axis *Axis, i int, val complex128) {
+    term := &axis.Terms[i]
+    delta := val-term.Val
+    term.Val = val
+    N := len(axis.Terms)
+    for n := range m.Path {
+        arg := 2*math.Pi*float64(n)*float64(term.Freq)/float64(N)
+        d := real(delta*complex(math.Cos(arg), math.Sin(arg)))/float64(N)
+        if (axis == &m.X) {
+            m.Path[n].X += d
+        } else {
+            m.Path[n].Y += d
+        }
+    }
+}
+
+// Len is the number of samples in one turn of the drawing.
+func (m *Model) Len() int {
+    return len(m.Path)
+}
+
+// Frame is the state of the model at one time.
+type Frame struct {
+    T       float64
+    X, Y    Chain
+    // Pen is the point being drawn: the x of the X tip and the y of the Y tip.
+    Pen     Vec
+    // Path is the part of the drawing traced before T.
+    Path    []Vec
+}
+
+func (m *Model) Frame(t float64) Frame {
+    f := Frame{T: t, X: m.X.Chain(t), Y: m.Y.Chain(t)}
+    f.Pen = Vec{f.X.Tip.X, f.Y.Tip.Y}
+    f.Path = m.Path[:max(0, min(int(t), len(m.Path)))]
+    return f
+}
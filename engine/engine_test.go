@@ -0,0 +1,114 @@
+package engine
+
+import (
+    "math"
+    "testing"
+
+    "fourier-drawing/fourier"
+)
+
+var xs = []float64{10, 40, 70, 60, 20, -5, 0}
+var ys = []float64{0, -10, 15, 50, 45, 30, 12}
+
+// newTestModel transforms xs and ys with their chains anchored at center, as the
+// app does, so the pen lands on the path at every sample.
+func newTestModel(sortByModule bool) *Model {
+    center := Vec{100, 200}
+    return NewModel(
+        Axis{Terms: fourier.DiscreteFourierTransform(xs, sortByModule), Origin: center},
+        Axis{Terms: fourier.DiscreteFourierTransform(ys, sortByModule), Origin: center, Phase: -math.Pi/2},
+        center,
+    )
+}
+
+func near(a, b Vec) bool {
+    return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+func TestModelPath(t *testing.T) {
+    for _, sorted := range []bool{false, true} {
+        m := newTestModel(sorted)
+        if (m.Len() != len(xs)) {
+            t.Fatalf("Len() = %d, want %d", m.Len(), len(xs))
+        }
+        for n, p := range m.Path {
+            want := Vec{xs[n]+100, ys[n]+200}
+            if (!near(p, want)) {
+                t.Errorf("sorted %v: Path[%d] = %v, want %v", sorted, n, p, want)
+            }
+            if pen := m.Frame(float64(n)).Pen; !near(pen, want) {
+                t.Errorf("sorted %v: pen at %d = %v, want %v", sorted, n, pen, want)
+            }
+        }
+    }
+}
+
+func TestFramePath(t *testing.T) {
+    m := newTestModel(true)
+    tests := []struct {
+        t       float64
+        want    int
+    }{
+        {-1, 0},
+        {0, 0},
+        {2.5, 2},
+        {float64(len(xs)), len(xs)},
+        {100, len(xs)},
+    }
+    for _, test := range tests {
+        if got := len(m.Frame(test.t).Path); got != test.want {
+            t.Errorf("Frame(%v) traced %d points, want %d", test.t, got, test.want)
+        }
+    }
+}
+
+func TestChainSkipsZeroTerms(t *testing.T) {
+    a := Axis{Terms: []fourier.FourierElement{{Freq: 0, Val: 8}, {Freq: 1, Val: 0}, {Freq: 2, Val: 4i}, {Freq: 3, Val: 0}}}
+    chain := a.Chain(0)
+    if (len(chain.Epicycles) != 2) {
+        t.Errorf("chain has %d epicycles, want the 2 non-zero terms", len(chain.Epicycles))
+    }
+    if (chain.MaxRadius != 2 || chain.N != 4) {
+        t.Errorf("MaxRadius, N = %v, %d, want 2, 4", chain.MaxRadius, chain.N)
+    }
+    for i := 1; i < len(chain.Epicycles); i++ {
+        if (chain.Epicycles[i].Center != chain.Epicycles[i-1].End) {
+            t.Errorf("epicycle %d starts at %v, not at the end of the one before", i, chain.Epicycles[i].Center)
+        }
+    }
+}
+
+// TestSetTerm checks the path updated by difference against a full trace.
+func TestSetTerm(t *testing.T) {
+    tests := []struct {
+        name    string
+        y       bool
+        i       int
+        val     complex128
+    }{
+        {"x mute", false, 1, 0},
+        {"y scale", true, 2, 3-4i},
+        {"x constant", false, 0, 700},
+        {"y last", true, len(ys)-1, 1i},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            m := newTestModel(true)
+            axis := &m.X
+            if (test.y) {
+                axis = &m.Y
+            }
+            m.SetTerm(axis, test.i, test.val)
+            if (axis.Terms[test.i].Val != test.val) {
+                t.Errorf("term %d = %v, want %v", test.i, axis.Terms[test.i].Val, test.val)
+            }
+            updated := append([]Vec(nil), m.Path...)
+            m.Trace()
+            for n := range updated {
+                if (!near(updated[n], m.Path[n])) {
+                    t.Errorf("Path[%d] = %v after SetTerm, %v after Trace", n, updated[n], m.Path[n])
+                }
+            }
+        })
+    }
+}
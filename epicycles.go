@@ -0,0 +1,64 @@
+package main
+
+import (
+    "image/color"
+    "math"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/ebitenutil"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/fourier"
+)
+
+// EpicycleStyle controls how drawFourierEpicycles draws each term of the chain.
+type EpicycleStyle struct {
+    // colorByFrequency tints each term by the sign of its frequency, brighter for larger terms.
+    colorByFrequency    bool
+    // minRadius hides the circles and radii of the terms smaller than this many pixels.
+    minRadius           float64
+    arrows              bool
+    filledDiscs         bool
+}
+
+// scaleAlpha returns c with its alpha multiplied by scale, as a straight-alpha color.
+func scaleAlpha(c HexColor, scale float64) color.NRGBA {
+    return color.NRGBA{c.R, c.G, c.B, uint8(math.Max(0, math.Min(255, float64(c.A)*scale)))}
+}
+
+// epicycleColors returns the radius and circle colors of a term.
+func (s *EpicycleStyle) epicycleColors(theme *Theme, freq, N int, radius, maxRadius float64) (radiusColor, circleColor color.Color) {
+    if !s.colorByFrequency {
+        return theme.Radius, theme.Epicycles
+    }
+    base := theme.PositiveFreq
+    if fourier.SignedFrequency(freq, N) < 0 {
+        base = theme.NegativeFreq
+    }
+    // Every radius is 0 when the drawing is a single point; show them at full strength.
+    strength := 1.0
+    if (maxRadius > 0) {
+        strength = 0.25+0.75*math.Sqrt(radius/maxRadius)
+    }
+    return scaleAlpha(base, strength), scaleAlpha(base, strength*0.8)
+}
+
+// drawArrowhead draws two short strokes at the end of the segment (x0, y0)-(x1, y1).
+func drawArrowhead(screen *ebiten.Image, x0, y0, x1, y1 float64, clr color.Color) {
+    length := math.Hypot(x1-x0, y1-y0)
+    if length < 4 {
+        return
+    }
+    size := math.Min(10, length*0.3)
+    angle := math.Atan2(y1-y0, x1-x0)
+    for _, side := range []float64{-1, 1} {
+        a := angle+math.Pi+side*math.Pi/7
+        ebitenutil.DrawLine(screen, x1, y1, x1+size*math.Cos(a), y1+size*math.Sin(a), clr)
+    }
+}
+
+func drawFilledDisc(screen *ebiten.Image, cx, cy, radius float64, clr color.Color) {
+    fill := color.NRGBAModel.Convert(clr).(color.NRGBA)
+    fill.A = uint8(float64(fill.A)*0.15)
+    vector.DrawFilledCircle(screen, float32(cx), float32(cy), float32(radius), fill, true)
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+    "fmt"
+    "image/color"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+    "fourier-drawing/ui"
+)
+
+type FileBrowserMode int
+const (
+    OPEN_FILE FileBrowserMode = iota
+    SAVE_FILE
+)
+
+// FileFilter matches files by extension; an empty list matches every file.
+type FileFilter struct {
+    name        string
+    extensions  []string
+}
+
+func (f FileFilter) Matches(name string) bool {
+    if len(f.extensions) == 0 {
+        return true
+    }
+    ext := strings.ToLower(filepath.Ext(name))
+    for _, e := range f.extensions {
+        if ext == e {
+            return true
+        }
+    }
+    return false
+}
+
+var pointFileFilters = []FileFilter{
+    {"Text files (*.txt)", []string{".txt"}},
+    {"All files (*)", nil},
+}
+
+const (
+    BROWSER_X = 460.0
+    BROWSER_Y = 120.0
+    BROWSER_WIDTH = 1000.0
+    BROWSER_HEIGHT = 840.0
+    BROWSER_ROW_HEIGHT = 22.0
+    BROWSER_VISIBLE_ROWS = 27
+)
+
+// FileBrowser is an in-app replacement for the native file dialog.
+// It runs inside the game loop, so opening it never blocks Update.
+type FileBrowser struct {
+    mode                FileBrowserMode
+    dir                 string
+    entries             []os.DirEntry
+    filters             []FileFilter
+    filterIndex         int
+    filename            string
+    selected            int
+    scroll              int
+    confirmOverwrite    bool
+    message             string
+    previousState       GameState
+    onDone              func(g *Game, filePath string) error
+}
+
+func (g *Game) openFileBrowser(mode FileBrowserMode, filters []FileFilter, onDone func(g *Game, filePath string) error) {
+    dir, err := os.Getwd()
+    if err != nil {
+        dir = "."
+    }
+    if g.fileBrowser != nil && g.fileBrowser.dir != "" {
+        dir = g.fileBrowser.dir
+    }
+
+    fb := &FileBrowser{
+        mode:           mode,
+        dir:            dir,
+        filters:        filters,
+        previousState:  g.state,
+        onDone:         onDone,
+    }
+
+    g.fileBrowser = fb
+    g.setFileFilter(0)
+    g.setState(BROWSING)
+}
+
+func (g *Game) buildFileBrowserWidgets() {
+    buttonY := BROWSER_Y+BROWSER_HEIGHT-60
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "browser-filter", Bounds: ui.Rect{X: BROWSER_X+20, Y: buttonY, W: 300, H: 40}, Screens: screens(BROWSING)},
+        OnClick:    func() { g.setFileFilter(g.fileBrowser.filterIndex+1) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "browser-ok", Bounds: ui.Rect{X: BROWSER_X+BROWSER_WIDTH-260, Y: buttonY, W: 110, H: 40}, Screens: screens(BROWSING)},
+        Label:      "OK",
+        OnClick:    func() { g.fileBrowser.confirm(g) },
+    })
+    g.widgets.Add(&ui.Button{
+        Base:       ui.Base{ID: "browser-cancel", Bounds: ui.Rect{X: BROWSER_X+BROWSER_WIDTH-130, Y: buttonY, W: 110, H: 40}, Screens: screens(BROWSING)},
+        Label:      "Cancel",
+        OnClick:    func() { g.fileBrowser.cancel(g) },
+    })
+}
+
+func (fb *FileBrowser) readDir() {
+    fb.entries = nil
+    fb.selected = -1
+    fb.scroll = 0
+    fb.confirmOverwrite = false
+
+    entries, err := os.ReadDir(fb.dir)
+    if err != nil {
+        fb.message = err.Error()
+        return
+    }
+    for _, entry := range entries {
+        if strings.HasPrefix(entry.Name(), ".") {
+            continue
+        }
+        if entry.IsDir() || fb.filters[fb.filterIndex].Matches(entry.Name()) {
+            fb.entries = append(fb.entries, entry)
+        }
+    }
+    sort.SliceStable(fb.entries, func (i, j int) (bool) {
+        if fb.entries[i].IsDir() != fb.entries[j].IsDir() {
+            return fb.entries[i].IsDir()
+        }
+        return strings.ToLower(fb.entries[i].Name()) < strings.ToLower(fb.entries[j].Name())
+    })
+}
+
+func (g *Game) setFileFilter(index int) {
+    fb := g.fileBrowser
+    fb.filterIndex = index%len(fb.filters)
+    fb.readDir()
+    g.widgets.Get("browser-filter").(*ui.Button).Label = fb.filters[fb.filterIndex].name
+}
+
+func (fb *FileBrowser) enterDir(dir string) {
+    fb.dir = filepath.Clean(dir)
+    fb.message = ""
+    fb.readDir()
+}
+
+// rowName returns the name shown in the list; row 0 is always the parent directory.
+func (fb *FileBrowser) rowName(row int) string {
+    if row == 0 {
+        return ".."
+    }
+    entry := fb.entries[row-1]
+    if entry.IsDir() {
+        return entry.Name() + string(filepath.Separator)
+    }
+    return entry.Name()
+}
+
+func (fb *FileBrowser) activateRow(g *Game, row int) {
+    if row == 0 {
+        fb.enterDir(filepath.Dir(fb.dir))
+        return
+    }
+    entry := fb.entries[row-1]
+    if entry.IsDir() {
+        fb.enterDir(filepath.Join(fb.dir, entry.Name()))
+        return
+    }
+    if fb.selected == row {
+        fb.confirm(g)
+        return
+    }
+    fb.selectRow(row)
+}
+
+// selectRow highlights row and, for a file, puts its name in the file name field.
+func (fb *FileBrowser) selectRow(row int) {
+    fb.selected = row
+    fb.confirmOverwrite = false
+    if row > 0 && !fb.entries[row-1].IsDir() {
+        fb.filename = fb.entries[row-1].Name()
+    }
+}
+
+func (fb *FileBrowser) confirm(g *Game) {
+    name := strings.TrimSpace(fb.filename)
+    if name == "" {
+        fb.message = "Enter a file name."
+        return
+    }
+    filePath := name
+    if !filepath.IsAbs(filePath) {
+        filePath = filepath.Join(fb.dir, name)
+    }
+
+    info, err := os.Stat(filePath)
+    if err == nil && info.IsDir() {
+        fb.filename = ""
+        fb.enterDir(filePath)
+        return
+    }
+
+    switch fb.mode {
+    case OPEN_FILE:
+        if err != nil {
+            fb.message = "File not found: " + name
+            return
+        }
+    case SAVE_FILE:
+        filter := fb.filters[fb.filterIndex]
+        if filepath.Ext(filePath) == "" && len(filter.extensions) > 0 {
+            filePath += filter.extensions[0]
+            info, err = os.Stat(filePath)
+        }
+        if err == nil && !fb.confirmOverwrite {
+            fb.confirmOverwrite = true
+            fb.message = fmt.Sprintf("%s already exists. Press OK or Y to overwrite, N to keep it.", filepath.Base(filePath))
+            return
+        }
+    }
+
+    if err := fb.onDone(g, filePath); err != nil {
+        fb.message = err.Error()
+        fb.confirmOverwrite = false
+        return
+    }
+    fb.dir = filepath.Dir(filePath)
+    // onDone may have moved on to another state already.
+    if (g.state == BROWSING) {
+        g.setState(fb.previousState)
+    }
+}
+
+func (fb *FileBrowser) cancel(g *Game) {
+    g.setState(fb.previousState)
+}
+
+func (g *Game) updateFileBrowser(pointerCaptured bool) {
+    fb := g.fileBrowser
+    if (g.state != BROWSING) {
+        return
+    }
+
+    if fb.confirmOverwrite {
+        if g.input.KeyJustPressed(ebiten.KeyY) || g.input.KeyJustPressed(ebiten.KeyEnter) {
+            fb.confirm(g)
+        } else if g.input.KeyJustPressed(ebiten.KeyN) || g.input.KeyJustPressed(ebiten.KeyEscape) {
+            fb.confirmOverwrite = false
+            fb.message = ""
+        }
+        return
+    }
+
+    if g.input.KeyJustPressed(ebiten.KeyEscape) {
+        fb.cancel(g)
+        return
+    }
+    if g.input.KeyJustPressed(ebiten.KeyEnter) && g.widgets.Focused() == nil {
+        // A selected row opens as if clicked again; otherwise the typed name is used.
+        if fb.selected >= 0 {
+            fb.activateRow(g, fb.selected)
+        } else {
+            fb.confirm(g)
+        }
+        return
+    }
+
+    if len(g.input.chars) > 0 {
+        fb.filename += g.input.chars
+        fb.selected = -1
+    }
+    if g.input.RepeatingKeyPressed(ebiten.KeyBackspace) && len(fb.filename) > 0 {
+        runes := []rune(fb.filename)
+        fb.filename = string(runes[:len(runes)-1])
+        fb.selected = -1
+    }
+
+    rows := len(fb.entries)+1
+    if g.input.RepeatingKeyPressed(ebiten.KeyDown) && fb.selected < rows-1 {
+        fb.selectRow(fb.selected+1)
+    }
+    if g.input.RepeatingKeyPressed(ebiten.KeyUp) && fb.selected > 0 {
+        fb.selectRow(fb.selected-1)
+    }
+    if fb.selected >= 0 {
+        if fb.selected < fb.scroll {
+            fb.scroll = fb.selected
+        } else if fb.selected >= fb.scroll+BROWSER_VISIBLE_ROWS {
+            fb.scroll = fb.selected-BROWSER_VISIBLE_ROWS+1
+        }
+    }
+
+    if g.input.pointer.Wheel != 0 {
+        fb.scroll -= int(g.input.pointer.Wheel*3)
+    }
+    fb.scroll = max(0, min(fb.scroll, rows-BROWSER_VISIBLE_ROWS))
+
+    if !pointerCaptured && g.input.PointerJustPressed() {
+        mouseX, mouseY := g.input.pointer.X, g.input.pointer.Y
+        listY := BROWSER_Y+70
+        if mouseX>=BROWSER_X && mouseX<=BROWSER_X+BROWSER_WIDTH && mouseY>=listY && mouseY<listY+BROWSER_VISIBLE_ROWS*BROWSER_ROW_HEIGHT {
+            row := fb.scroll+int((mouseY-listY)/BROWSER_ROW_HEIGHT)
+            if row < rows {
+                fb.activateRow(g, row)
+            }
+        }
+    }
+}
+
+func (g *Game) drawFileBrowser(screen *ebiten.Image) {
+    fb := g.fileBrowser
+
+    ebitenutil.DrawRect(screen, BROWSER_X-5, BROWSER_Y-5, BROWSER_WIDTH+10, BROWSER_HEIGHT+10, g.theme.UIBorder)
+    ebitenutil.DrawRect(screen, BROWSER_X, BROWSER_Y, BROWSER_WIDTH, BROWSER_HEIGHT, g.theme.UIBackground)
+
+    title := "Open drawing"
+    if fb.mode == SAVE_FILE {
+        title = "Save drawing"
+    }
+    ui.DrawText(screen, title, 22, BROWSER_X+20, BROWSER_Y+10, g.theme.Text)
+    ui.DrawText(screen, fb.dir, 16, BROWSER_X+20, BROWSER_Y+42, g.theme.UIDisabled)
+
+    listY := BROWSER_Y+70
+    for i:=0; i<BROWSER_VISIBLE_ROWS; i++ {
+        row := fb.scroll+i
+        if row > len(fb.entries) {
+            break
+        }
+        y := listY+float64(i)*BROWSER_ROW_HEIGHT
+        textColor := color.Color(g.theme.Text)
+        if row == fb.selected {
+            ebitenutil.DrawRect(screen, BROWSER_X+10, y, BROWSER_WIDTH-20, BROWSER_ROW_HEIGHT, g.theme.UIHover)
+        }
+        if row == 0 || fb.entries[row-1].IsDir() {
+            textColor = g.theme.UIAccent
+        }
+        ui.DrawText(screen, fb.rowName(row), 16, BROWSER_X+20, y+2, textColor)
+    }
+
+    fieldY := BROWSER_Y+BROWSER_HEIGHT-130
+    ui.DrawText(screen, "File name:", 16, BROWSER_X+20, fieldY+5, g.theme.Text)
+    ebitenutil.DrawRect(screen, BROWSER_X+110, fieldY, BROWSER_WIDTH-130, 30, g.theme.UIBorder)
+    ebitenutil.DrawRect(screen, BROWSER_X+112, fieldY+2, BROWSER_WIDTH-134, 26, g.theme.UIBackground)
+    ui.DrawText(screen, fb.filename+"_", 16, BROWSER_X+120, fieldY+5, g.theme.Text)
+
+    if fb.message != "" {
+        ui.DrawText(screen, fb.message, 16, BROWSER_X+20, fieldY+40, g.theme.Error)
+    }
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "strconv"
+    "strings"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/engine"
+    "fourier-drawing/fourier"
+    "fourier-drawing/ui"
+)
+
+type FilterKind int
+const (
+    NO_FILTER FilterKind = iota
+    LOW_PASS_FILTER
+    HIGH_PASS_FILTER
+    BAND_PASS_FILTER
+    NOTCH_FILTER
+    FILTER_KINDS
+)
+
+var filterKindNames = [FILTER_KINDS]string{"none", "low-pass", "high-pass", "band-pass", "notch"}
+
+type FilterShape int
+const (
+    IDEAL_FILTER FilterShape = iota
+    GAUSSIAN_FILTER
+    BUTTERWORTH_FILTER
+    FILTER_SHAPES
+)
+
+var filterShapeNames = [FILTER_SHAPES]string{"ideal", "gaussian", "butterworth"}
+
+// SpectrumFilter is the frequency-domain filter applied to both spectra before the
+// reconstruction. Frequencies are in cycles per drawing.
+type SpectrumFilter struct {
+    kind        FilterKind
+    shape       FilterShape
+    // cutoff is the cutoff of the low and high-pass filters and the bottom of the band.
+    cutoff      float64
+    cutoffHigh  float64
+    order       int
+    notches     []int
+}
+
+func (f *SpectrumFilter) active() bool {
+    return f != nil && f.kind != NO_FILTER
+}
+
+func (f *SpectrumFilter) lowPass(cutoff float64) fourier.Response {
+    switch f.shape {
+    case GAUSSIAN_FILTER:
+        return fourier.GaussianLowPass(cutoff)
+    case BUTTERWORTH_FILTER:
+        return fourier.ButterworthLowPass(cutoff, f.order)
+    }
+    return fourier.IdealLowPass(cutoff)
+}
+
+// apply returns the filtered copy of X, sorted by module again.
+func (f *SpectrumFilter) apply(X []fourier.FourierElement) []fourier.FourierElement {
+    if (!f.active()) {
+        return X
+    }
+    switch f.kind {
+    case LOW_PASS_FILTER:
+        X = fourier.ApplyFilter(X, f.lowPass(f.cutoff))
+    case HIGH_PASS_FILTER:
+        X = fourier.ApplyFilter(X, fourier.HighPass(f.lowPass(f.cutoff)))
+    case BAND_PASS_FILTER:
+        X = fourier.ApplyFilter(X, fourier.BandPass(f.lowPass(f.cutoffHigh), fourier.HighPass(f.lowPass(f.cutoff))))
+    case NOTCH_FILTER:
+        X = fourier.Notch(X, f.notches)
+    }
+    fourier.SortByModule(X)
+    return X
+}
+
+func (f *SpectrumFilter) String() string {
+    switch f.kind {
+    case NO_FILTER:
+        return "no filter"
+    case NOTCH_FILTER:
+        return fmt.Sprintf("notch %v", f.notches)
+    case BAND_PASS_FILTER:
+        return fmt.Sprintf("%s band-pass %g-%g", filterShapeNames[f.shape], f.cutoff, f.cutoffHigh)
+    }
+    return fmt.Sprintf("%s %s %g", filterShapeNames[f.shape], filterKindNames[f.kind], f.cutoff)
+}
+
+// addFilterFlags registers the filter flags on flags. The returned function checks
+// and stores them once the flags are parsed.
+func addFilterFlags(flags *flag.FlagSet, f *SpectrumFilter) func() error {
+    var kind, shape, notches string
+    flags.StringVar(&kind, "filter", "none", "filter applied before the reconstruction: none, low-pass, high-pass, band-pass or notch")
+    flags.StringVar(&shape, "filter-shape", "ideal", "filter shape: ideal, gaussian or butterworth")
+    flags.Float64Var(&f.cutoff, "cutoff", 10, "cutoff frequency of the low and high-pass filters, bottom of the band-pass, in cycles per drawing")
+    flags.Float64Var(&f.cutoffHigh, "cutoff-high", 30, "top of the band-pass, in cycles per drawing")
+    flags.IntVar(&f.order, "filter-order", 2, "order of the butterworth filters")
+    flags.StringVar(&notches, "notch", "", "frequencies removed by the notch filter, e.g. 3,5,7")
+
+    return func() error {
+        found := false
+        for i, name := range filterKindNames {
+            if (name == strings.ToLower(kind)) {
+                f.kind, found = FilterKind(i), true
+            }
+        }
+        if (!found) {
+            return fmt.Errorf("invalid -filter value %q", kind)
+        }
+        found = false
+        for i, name := range filterShapeNames {
+            if (name == strings.ToLower(shape)) {
+                f.shape, found = FilterShape(i), true
+            }
+        }
+        if (!found) {
+            return fmt.Errorf("invalid -filter-shape value %q", shape)
+        }
+        if (f.cutoff <= 0 || f.cutoffHigh <= f.cutoff || f.order < 1) {
+            return fmt.Errorf("-cutoff must be positive, -cutoff-high above it and -filter-order at least 1")
+        }
+        f.notches = nil
+        for _, field := range strings.FieldsFunc(notches, func(r rune) bool { return r == ',' || r == ' ' }) {
+            n, err := strconv.Atoi(field)
+            if err != nil || n <= 0 {
+                return fmt.Errorf("invalid -notch frequency %q", field)
+            }
+            f.notches = append(f.notches, n)
+        }
+        if (f.kind == NOTCH_FILTER && len(f.notches) == 0) {
+            return fmt.Errorf("-filter notch needs the frequencies to remove in -notch")
+        }
+        return nil
+    }
+}
+
+func (g *Game) setFilterKind(kind FilterKind) {
+    g.options.filter.kind = (kind+FILTER_KINDS)%FILTER_KINDS
+    // Without -notch there is nothing for a notch filter to remove.
+    if (g.options.filter.kind == NOTCH_FILTER && len(g.options.filter.notches) == 0) {
+        g.options.filter.kind = (g.options.filter.kind+1)%FILTER_KINDS
+    }
+    g.widgets.Get("filter-kind").(*ui.Button).Label = "Filter: " + filterKindNames[g.options.filter.kind]
+}
+
+func (g *Game) setFilterShape(shape FilterShape) {
+    g.options.filter.shape = (shape+FILTER_SHAPES)%FILTER_SHAPES
+    g.widgets.Get("filter-shape").(*ui.Button).Label = "Shape: " + filterShapeNames[g.options.filter.shape]
+}
+
+// drawFilterOverlay compares the drawing before the filter, faint in the dots color,
+// with the whole filtered one, faint in the trail color.
+func (g *Game) drawFilterOverlay(screen *ebiten.Image) {
+    for _, curve := range []struct{ path []engine.Vec; clr HexColor }{{g.unfilteredPath, g.theme.Dots}, {g.model.Path, g.theme.Trail}} {
+        path := g.camera.ToScreenPath(curve.path)
+        for i := range path {
+            a, b := path[i], path[(i+1)%len(path)]
+            vector.StrokeLine(screen, float32(a.x), float32(a.y), float32(b.x), float32(b.y), 1, scaleAlpha(curve.clr, 0.35), true)
+        }
+    }
+    x, y := float64(g.windowSize.width)/2-200, float64(g.windowSize.height)-40
+    ui.DrawText(screen, "before", ui.DEFAULT_TEXT_SIZE, x, y, g.theme.Dots)
+    ui.DrawText(screen, "after: " + g.options.filter.String(), ui.DEFAULT_TEXT_SIZE, x+80, y, g.theme.Trail)
+}
@@ -0,0 +1,101 @@
+package fourier
+
+import (
+    "math"
+    "math/cmplx"
+    "sort"
+)
+
+// Response is the gain of a filter at a frequency, in cycles per turn of the sequence.
+type Response func(f float64) (float64)
+
+// SignedFrequency returns the frequency of the element of an N-point transform at
+// bin k, in (-N/2, N/2].
+func SignedFrequency(k, N int) (int) {
+    if (k > N/2) {
+        return k-N
+    }
+    return k
+}
+
+func IdealLowPass(cutoff float64) (Response) {
+    return func(f float64) (float64) {
+        if (f <= cutoff) {
+            return 1
+        }
+        return 0
+    }
+}
+
+// GaussianLowPass falls off smoothly, to about 0.61 at the cutoff.
+func GaussianLowPass(cutoff float64) (Response) {
+    return func(f float64) (float64) {
+        return math.Exp(-f*f/(2*cutoff*cutoff))
+    }
+}
+
+// ButterworthLowPass is flat below the cutoff and steeper past it with a higher order.
+func ButterworthLowPass(cutoff float64, order int) (Response) {
+    return func(f float64) (float64) {
+        return 1/math.Sqrt(1+math.Pow(f/cutoff, float64(2*order)))
+    }
+}
+
+// HighPass turns a low-pass response into the high-pass one with the same shape.
+func HighPass(lowPass Response) (Response) {
+    return func(f float64) (float64) {
+        return 1-lowPass(f)
+    }
+}
+
+// BandPass keeps what both passHigh, a low-pass response at the top of the band,
+// and passLow, a high-pass response at its bottom, keep.
+func BandPass(passHigh, passLow Response) (Response) {
+    return func(f float64) (float64) {
+        return passHigh(f)*passLow(f)
+    }
+}
+
+// ApplyFilter returns a copy of X with every element scaled by the response at the
+// magnitude of its frequency, so both rotation directions are treated alike. The
+// constant term, which only places the sequence, is left alone.
+func ApplyFilter(X []FourierElement, response Response) ([]FourierElement) {
+    N := len(X)
+    Y := make([]FourierElement, N)
+    copy(Y, X)
+
+    for i := range Y {
+        f := SignedFrequency(Y[i].Freq, N)
+        if (f == 0) {
+            continue
+        }
+        Y[i].Val *= complex(response(math.Abs(float64(f))), 0)
+    }
+
+    return Y
+}
+
+// Notch returns a copy of X without the given frequencies, in both directions.
+func Notch(X []FourierElement, frequencies []int) ([]FourierElement) {
+    N := len(X)
+    Y := make([]FourierElement, N)
+    copy(Y, X)
+
+    for i := range Y {
+        f := SignedFrequency(Y[i].Freq, N)
+        for _, notch := range frequencies {
+            if (f == notch || f == -notch) {
+                Y[i].Val = 0
+            }
+        }
+    }
+
+    return Y
+}
+
+// SortByModule sorts X in place, largest elements first.
+func SortByModule(X []FourierElement) {
+    sort.SliceStable(X, func (i, j int) (bool) {
+        return cmplx.Abs(X[i].Val) > cmplx.Abs(X[j].Val)
+    })
+}
@@ -0,0 +1,120 @@
+package fourier
+
+import (
+    "math"
+    "testing"
+)
+
+func TestSignedFrequency(t *testing.T) {
+    tests := []struct {
+        k, N    int
+        want    int
+    }{
+        {0, 8, 0},
+        {3, 8, 3},
+        {4, 8, 4},
+        {5, 8, -3},
+        {7, 8, -1},
+        {3, 7, 3},
+        {4, 7, -3},
+        {6, 7, -1},
+        {0, 1, 0},
+    }
+    for _, test := range tests {
+        if got := SignedFrequency(test.k, test.N); got != test.want {
+            t.Errorf("SignedFrequency(%d, %d) = %d, want %d", test.k, test.N, got, test.want)
+        }
+    }
+}
+
+func TestResponses(t *testing.T) {
+    tests := []struct {
+        name        string
+        response    Response
+        f           float64
+        want        float64
+    }{
+        {"ideal below", IdealLowPass(5), 4, 1},
+        {"ideal at the cutoff", IdealLowPass(5), 5, 1},
+        {"ideal past the cutoff", IdealLowPass(5), 5.5, 0},
+        {"gaussian at 0", GaussianLowPass(4), 0, 1},
+        {"gaussian at the cutoff", GaussianLowPass(4), 4, math.Exp(-0.5)},
+        {"gaussian at twice the cutoff", GaussianLowPass(4), 8, math.Exp(-2)},
+        {"butterworth at 0", ButterworthLowPass(4, 2), 0, 1},
+        {"butterworth at the cutoff", ButterworthLowPass(4, 2), 4, 1/math.Sqrt2},
+        {"butterworth order 1 at the cutoff", ButterworthLowPass(4, 1), 4, 1/math.Sqrt2},
+        {"butterworth at twice the cutoff", ButterworthLowPass(4, 2), 8, 1/math.Sqrt(17)},
+        {"high-pass at 0", HighPass(GaussianLowPass(4)), 0, 0},
+        {"high-pass at the cutoff", HighPass(ButterworthLowPass(4, 2)), 4, 1-1/math.Sqrt2},
+        {"high-pass past an ideal cutoff", HighPass(IdealLowPass(5)), 6, 1},
+        {"band-pass at 0", BandPass(IdealLowPass(10), HighPass(IdealLowPass(2))), 0, 0},
+        {"band-pass inside", BandPass(IdealLowPass(10), HighPass(IdealLowPass(2))), 5, 1},
+        {"band-pass at the top", BandPass(IdealLowPass(10), HighPass(IdealLowPass(2))), 10, 1},
+        {"band-pass above", BandPass(IdealLowPass(10), HighPass(IdealLowPass(2))), 11, 0},
+        {"band-pass at the bottom", BandPass(IdealLowPass(10), HighPass(IdealLowPass(2))), 2, 0},
+        {"smooth band-pass", BandPass(ButterworthLowPass(10, 3), HighPass(ButterworthLowPass(2, 3))), 10, (1/math.Sqrt2)*(1-1/math.Sqrt(1+math.Pow(5, 6)))},
+    }
+    for _, test := range tests {
+        if got := test.response(test.f); math.Abs(got-test.want) > 1e-12 {
+            t.Errorf("%s: response(%v) = %v, want %v", test.name, test.f, got, test.want)
+        }
+    }
+}
+
+// spectrum returns N elements with value k+1 at frequency k, shuffled as a sorted
+// spectrum would be, so the filters must go by Freq and not by position.
+func spectrum(N int) []FourierElement {
+    X := make([]FourierElement, N)
+    for i := range X {
+        k := (i*3)%N
+        X[i] = FourierElement{Freq: k, Val: complex(float64(k+1), 0)}
+    }
+    return X
+}
+
+func TestApplyFilter(t *testing.T) {
+    const N = 8
+    X := spectrum(N)
+    Y := ApplyFilter(X, IdealLowPass(2))
+    for i, element := range Y {
+        f := SignedFrequency(element.Freq, N)
+        want := X[i].Val
+        if (f > 2 || f < -2) {
+            want = 0
+        }
+        if (element.Freq != X[i].Freq || element.Val != want) {
+            t.Errorf("frequency %d (signed %d) = %v, want %v", element.Freq, f, element.Val, want)
+        }
+    }
+    if (X[0].Val != 1) {
+        t.Error("ApplyFilter changed its input")
+    }
+
+    // A response that rejects everything still leaves the constant term.
+    for _, element := range ApplyFilter(X, HighPass(IdealLowPass(math.Inf(1)))) {
+        if want := complex(float64(element.Freq+1), 0); element.Freq == 0 && element.Val != want {
+            t.Errorf("constant term = %v, want %v", element.Val, want)
+        } else if (element.Freq != 0 && element.Val != 0) {
+            t.Errorf("frequency %d = %v, want 0", element.Freq, element.Val)
+        }
+    }
+}
+
+func TestNotch(t *testing.T) {
+    const N = 8
+    X := spectrum(N)
+    for _, element := range Notch(X, []int{1, 4}) {
+        removed := element.Freq == 1 || element.Freq == 7 || element.Freq == 4
+        if want := complex(float64(element.Freq+1), 0); removed && element.Val != 0 {
+            t.Errorf("notched frequency %d = %v, want 0", element.Freq, element.Val)
+        } else if (!removed && element.Val != want) {
+            t.Errorf("frequency %d = %v, want it kept at %v", element.Freq, element.Val, want)
+        }
+    }
+    // Negative frequencies given to the notch remove both directions as well.
+    for _, element := range Notch(X, []int{-3}) {
+        if ((element.Freq == 3 || element.Freq == 5) && element.Val != 0) {
+            t.Errorf("frequency %d survived a notch at -3", element.Freq)
+        }
+    }
+}
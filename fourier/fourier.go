@@ -47,4 +47,39 @@ func InverseDFT(X []FourierElement) ([]float64) {
     }
 
     return x
-}
\ No newline at end of file
+}
+
+// KeepLargest returns a copy of X where only the first k elements keep their value.
+// X is expected to be sorted by module, so the k largest terms survive; the length
+// (and therefore N) is preserved so InverseDFT still reconstructs len(X) samples.
+func KeepLargest(X []FourierElement, k int) ([]FourierElement) {
+    Y := make([]FourierElement, len(X))
+    copy(Y, X)
+    if (k <= 0) {
+        return Y
+    }
+
+    for i:=k; i<len(Y); i++ {
+        Y[i].Val = 0
+    }
+
+    return Y
+}
+
+// ComplexDFT transforms a complex sequence, e.g. a contour with the points as x+iy.
+// Unlike the transform of a real sequence, the elements at k and N-k are unrelated:
+// they are the terms turning one way and the other at that frequency.
+func ComplexDFT(z []complex128) ([]FourierElement) {
+    N := len(z)
+    Z := make([]FourierElement, N)
+
+    for k:=0; k<N; k++ {
+        Z[k].Freq = k
+        for n:=0; n<N; n++ {
+            arg := 2 * math.Pi * float64(n) * float64(k) / float64(N);
+            Z[k].Val += z[n] * complex(math.Cos(arg), -math.Sin(arg))
+        }
+    }
+
+    return Z
+}
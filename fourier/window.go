@@ -0,0 +1,106 @@
+package fourier
+
+import (
+    "math"
+    "math/cmplx"
+)
+
+// Window is the weight of sample n of an N-point sequence. The windows below are
+// periodic, as suits a DFT: the weight of sample N would equal that of sample 0.
+type Window func(n, N int) (float64)
+
+func Rectangular(n, N int) (float64) {
+    return 1
+}
+
+func Hann(n, N int) (float64) {
+    return 0.5-0.5*math.Cos(2*math.Pi*float64(n)/float64(N))
+}
+
+func Hamming(n, N int) (float64) {
+    return 0.54-0.46*math.Cos(2*math.Pi*float64(n)/float64(N))
+}
+
+func Blackman(n, N int) (float64) {
+    arg := 2*math.Pi*float64(n)/float64(N)
+    return 0.42-0.5*math.Cos(arg)+0.08*math.Cos(2*arg)
+}
+
+// Tukey is flat in the middle and tapers as a Hann window over the fraction alpha of
+// the sequence, half at each end. Alpha 0 is the rectangular window, 1 the Hann one.
+func Tukey(alpha float64) (Window) {
+    return func(n, N int) (float64) {
+        taper := alpha*float64(N)/2
+        x := float64(n)
+        switch {
+        case taper <= 0:
+            return 1
+        case x < taper:
+            return 0.5-0.5*math.Cos(math.Pi*x/taper)
+        case x > float64(N)-taper:
+            return 0.5-0.5*math.Cos(math.Pi*(float64(N)-x)/taper)
+        }
+        return 1
+    }
+}
+
+// CoherentGain is the mean weight of window over N samples: the factor by which it
+// scales the amplitude of a sinusoid.
+func CoherentGain(window Window, N int) (float64) {
+    sum := 0.0
+    for n:=0; n<N; n++ {
+        sum += window(n, N)
+    }
+    return sum/float64(N)
+}
+
+// ApplyWindow returns a copy of x weighted by window.
+func ApplyWindow(x []float64, window Window) ([]float64) {
+    N := len(x)
+    y := make([]float64, N)
+    for n:=0; n<N; n++ {
+        y[n] = x[n]*window(n, N)
+    }
+    return y
+}
+
+// WindowedDFT transforms x weighted by window and divides the result by the coherent
+// gain of the window, so the terms keep the amplitudes of the unweighted transform.
+func WindowedDFT(x []float64, window Window, sortByModule bool) ([]FourierElement) {
+    X := DiscreteFourierTransform(ApplyWindow(x, window), sortByModule)
+    if (len(x) == 0) {
+        return X
+    }
+    gain := complex(CoherentGain(window, len(x)), 0)
+    for i := range X {
+        X[i].Val /= gain
+    }
+    return X
+}
+
+// AmplitudeSpectrum returns the amplitudes of the frequencies 0 to bins-1 of x
+// weighted by window, corrected for the coherent gain: a sinusoid of amplitude A at
+// frequency k reads A at bin k. Only the requested bins are computed.
+func AmplitudeSpectrum(x []float64, window Window, bins int) ([]float64) {
+    N := len(x)
+    bins = min(bins, N/2+1)
+    amplitudes := make([]float64, max(bins, 0))
+    if (N == 0) {
+        return amplitudes
+    }
+    y := ApplyWindow(x, window)
+    sum := CoherentGain(window, N)*float64(N)
+
+    for k := range amplitudes {
+        var val complex128
+        for n:=0; n<N; n++ {
+            arg := 2 * math.Pi * float64(n) * float64(k) / float64(N)
+            val += complex(y[n], 0) * complex(math.Cos(arg), -math.Sin(arg))
+        }
+        amplitudes[k] = cmplx.Abs(val)/sum
+        if (k > 0 && 2*k != N) {
+            amplitudes[k] *= 2
+        }
+    }
+    return amplitudes
+}
@@ -0,0 +1,98 @@
+package fourier
+
+import (
+    "math"
+    "math/cmplx"
+    "testing"
+)
+
+func TestWindowValues(t *testing.T) {
+    const N = 64
+    tests := []struct {
+        name        string
+        window      Window
+        first, peak float64
+    }{
+        {"rectangular", Rectangular, 1, 1},
+        {"hann", Hann, 0, 1},
+        {"hamming", Hamming, 0.08, 1},
+        {"blackman", Blackman, 0, 1},
+        {"tukey 0.5", Tukey(0.5), 0, 1},
+    }
+    for _, test := range tests {
+        if got := test.window(0, N); math.Abs(got-test.first) > 1e-12 {
+            t.Errorf("%s(0) = %v, want %v", test.name, got, test.first)
+        }
+        if got := test.window(N/2, N); math.Abs(got-test.peak) > 1e-12 {
+            t.Errorf("%s(N/2) = %v, want %v", test.name, got, test.peak)
+        }
+        // Periodic: sample n weighs as much as sample N-n, the last one as the second.
+        for n := 1; n < N; n++ {
+            if a, b := test.window(n, N), test.window(N-n, N); math.Abs(a-b) > 1e-12 {
+                t.Errorf("%s(%d) = %v but %s(%d) = %v", test.name, n, a, test.name, N-n, b)
+            }
+        }
+    }
+}
+
+func TestCoherentGain(t *testing.T) {
+    tests := []struct {
+        name    string
+        window  Window
+        want    float64
+    }{
+        {"rectangular", Rectangular, 1},
+        {"hann", Hann, 0.5},
+        {"hamming", Hamming, 0.54},
+        {"blackman", Blackman, 0.42},
+        {"tukey 0", Tukey(0), 1},
+        {"tukey 1", Tukey(1), 0.5},
+    }
+    for _, test := range tests {
+        if got := CoherentGain(test.window, 64); math.Abs(got-test.want) > 1e-12 {
+            t.Errorf("CoherentGain(%s) = %v, want %v", test.name, got, test.want)
+        }
+    }
+}
+
+func TestTukeyEdges(t *testing.T) {
+    for _, N := range []int{7, 64} {
+        for n := 0; n < N; n++ {
+            if got := Tukey(0)(n, N); got != 1 {
+                t.Errorf("Tukey(0)(%d, %d) = %v, want the rectangular 1", n, N, got)
+            }
+            if got, want := Tukey(1)(n, N), Hann(n, N); math.Abs(got-want) > 1e-12 {
+                t.Errorf("Tukey(1)(%d, %d) = %v, want the Hann %v", n, N, got, want)
+            }
+        }
+    }
+    // Between the tapers the window is flat.
+    for n := 16; n <= 48; n++ {
+        if got := Tukey(0.5)(n, 64); got != 1 {
+            t.Errorf("Tukey(0.5)(%d, 64) = %v, want 1", n, got)
+        }
+    }
+}
+
+func TestWindowedDFT(t *testing.T) {
+    const N, k, A = 64, 5, 3.0
+    x := make([]float64, N)
+    for n := range x {
+        x[n] = A*math.Cos(2*math.Pi*k*float64(n)/N+0.4)
+    }
+    for _, window := range []Window{Rectangular, Hann, Hamming, Blackman, Tukey(0.5)} {
+        X := WindowedDFT(x, window, false)
+        if got := 2*cmplx.Abs(X[k].Val)/N; math.Abs(got-A) > 1e-9 {
+            t.Errorf("amplitude at bin %d = %v, want %v", k, got, A)
+        }
+    }
+    plain := DiscreteFourierTransform(x, false)
+    for i, element := range WindowedDFT(x, Rectangular, false) {
+        if (cmplx.Abs(element.Val-plain[i].Val) > 1e-9) {
+            t.Errorf("rectangular term %d = %v, want the plain transform %v", i, element.Val, plain[i].Val)
+        }
+    }
+    if X := WindowedDFT(nil, Hann, true); len(X) != 0 {
+        t.Errorf("WindowedDFT(nil) = %v, want no terms", X)
+    }
+}
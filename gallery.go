@@ -0,0 +1,209 @@
+package main
+
+import (
+    "embed"
+    "fmt"
+    "image/color"
+    "io/fs"
+    "math"
+    "os"
+    "path"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+    "fourier-drawing/ui"
+)
+
+// The bundled sample drawings, so the gallery works without the repo checked out.
+//go:embed files/*.txt
+var sampleFiles embed.FS
+
+const (
+    THUMBNAIL_WIDTH = 280
+    THUMBNAIL_HEIGHT = 180
+    GALLERY_COLUMNS = 5
+    GALLERY_ROWS = 3
+)
+
+type GalleryEntry struct {
+    name        string
+    points      []Point
+    userAdded   bool
+    thumbnail   *ebiten.Image
+}
+
+// userGalleryDir is where drawings added from the gallery screen are stored.
+func userGalleryDir() (string, error) {
+    dir, err := os.UserConfigDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(dir, "fourier-drawing", "gallery"), nil
+}
+
+func loadGallery() []*GalleryEntry {
+    var entries []*GalleryEntry
+
+    samples, _ := fs.Glob(sampleFiles, "files/*.txt")
+    for _, sample := range samples {
+        file, err := sampleFiles.Open(sample)
+        if err != nil {
+            continue
+        }
+        points, err := parsePoints(file)
+        file.Close()
+        if err != nil || len(points) == 0 {
+            continue
+        }
+        name := strings.TrimSuffix(path.Base(sample), ".txt")
+        entries = append(entries, &GalleryEntry{name: name, points: points})
+    }
+
+    dir, err := userGalleryDir()
+    if err != nil {
+        return entries
+    }
+    files, err := os.ReadDir(dir)
+    if err != nil {
+        return entries
+    }
+    var userEntries []*GalleryEntry
+    for _, f := range files {
+        if f.IsDir() || filepath.Ext(f.Name()) != ".txt" {
+            continue
+        }
+        file, err := os.Open(filepath.Join(dir, f.Name()))
+        if err != nil {
+            continue
+        }
+        points, err := parsePoints(file)
+        file.Close()
+        if err != nil || len(points) == 0 {
+            continue
+        }
+        name := strings.TrimSuffix(f.Name(), ".txt")
+        userEntries = append(userEntries, &GalleryEntry{name: name, points: points, userAdded: true})
+    }
+    sort.Slice(userEntries, func (i, j int) (bool) {
+        return userEntries[i].name < userEntries[j].name
+    })
+
+    return append(entries, userEntries...)
+}
+
+func addToUserGallery(points []Point) error {
+    dir, err := userGalleryDir()
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+    name := "drawing-" + time.Now().Format("20060102-150405") + ".txt"
+    return writePointsToFile(filepath.Join(dir, name), points)
+}
+
+// drawThumbnail renders the points scaled to fit a THUMBNAIL_WIDTH x THUMBNAIL_HEIGHT image.
+func drawThumbnail(points []Point, lineColor color.Color) *ebiten.Image {
+    thumbnail := ebiten.NewImage(THUMBNAIL_WIDTH, THUMBNAIL_HEIGHT)
+    if len(points) == 0 {
+        return thumbnail
+    }
+
+    minX, minY := points[0].x, points[0].y
+    maxX, maxY := minX, minY
+    for _, p := range points {
+        minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+        minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+    }
+
+    padding := 10.0
+    scale := math.Min((THUMBNAIL_WIDTH-2*padding)/math.Max(maxX-minX, 1), (THUMBNAIL_HEIGHT-2*padding)/math.Max(maxY-minY, 1))
+    offsetX := (THUMBNAIL_WIDTH-(maxX-minX)*scale)/2
+    offsetY := (THUMBNAIL_HEIGHT-(maxY-minY)*scale)/2
+
+    for i:=1; i<len(points); i++ {
+        ebitenutil.DrawLine(thumbnail,
+            offsetX+(points[i-1].x-minX)*scale, offsetY+(points[i-1].y-minY)*scale,
+            offsetX+(points[i].x-minX)*scale, offsetY+(points[i].y-minY)*scale,
+            lineColor)
+    }
+
+    return thumbnail
+}
+
+func (g *Game) refreshGallery() {
+    for _, entry := range g.gallery {
+        if entry.thumbnail != nil {
+            entry.thumbnail.Deallocate()
+        }
+    }
+    g.widgets.RemoveIf(func(w ui.Widget) bool {
+        tile, ok := w.(*ui.Button)
+        return ok && strings.HasPrefix(tile.ID, "gallery-tile-")
+    })
+
+    // The guesses point into the old gallery.
+    g.guess.ranking = nil
+    g.setGuessOpen(false)
+    g.gallery = loadGallery()
+    for i, entry := range g.gallery {
+        entry.thumbnail = drawThumbnail(entry.points, g.theme.Dots)
+        label := entry.name
+        if entry.userAdded {
+            label = "* " + label
+        }
+        g.widgets.Add(&ui.Button{
+            Base:       ui.Base{ID: fmt.Sprintf("gallery-tile-%d", i), Bounds: ui.Rect{W: THUMBNAIL_WIDTH+20, H: THUMBNAIL_HEIGHT+50}, Screens: screens(GALLERY)},
+            Label:      label,
+            Image:      entry.thumbnail,
+            OnClick:    func() {
+                g.points = make([]Point, len(entry.points))
+                copy(g.points, entry.points)
+                g.setState(REVEALING)
+            },
+        })
+    }
+    g.galleryScroll = 0
+    g.layoutGallery()
+}
+
+// redrawThumbnails renders the thumbnails again, e.g. after the theme changed.
+func (g *Game) redrawThumbnails() {
+    for i, entry := range g.gallery {
+        entry.thumbnail.Deallocate()
+        entry.thumbnail = drawThumbnail(entry.points, g.theme.Dots)
+        g.widgets.Get(fmt.Sprintf("gallery-tile-%d", i)).(*ui.Button).Image = entry.thumbnail
+    }
+    g.showGuess()
+}
+
+// layoutGallery places the tiles on the grid according to galleryScroll and hides
+// the rows scrolled out of view.
+func (g *Game) layoutGallery() {
+    for i := range g.gallery {
+        tile := g.widgets.Get(fmt.Sprintf("gallery-tile-%d", i)).(*ui.Button)
+        row := i/GALLERY_COLUMNS - g.galleryScroll
+        col := i%GALLERY_COLUMNS
+        tile.Bounds.X = 60.0 + float64(col)*(tile.Bounds.W+60.0)
+        tile.Bounds.Y = 150.0 + float64(row)*(tile.Bounds.H+40.0)
+        tile.Hidden = row < 0 || row >= GALLERY_ROWS
+    }
+}
+
+func (g *Game) updateGallery() {
+    if wheelY := g.input.pointer.Wheel; wheelY != 0 {
+        rows := (len(g.gallery)+GALLERY_COLUMNS-1)/GALLERY_COLUMNS
+        if wheelY < 0 && g.galleryScroll+GALLERY_ROWS < rows {
+            g.galleryScroll++
+        } else if wheelY > 0 && g.galleryScroll > 0 {
+            g.galleryScroll--
+        }
+        g.layoutGallery()
+    }
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+
+    "fourier-drawing/descriptors"
+    "fourier-drawing/ui"
+)
+
+// GUESS_TRIES is how many gallery drawings are offered before giving up.
+const GUESS_TRIES = 3
+
+type GuessMatch struct {
+    entry       *GalleryEntry
+    distance    float64
+}
+
+// GuessGame is the "guess the drawing" mini-game: the sketch on the board is matched
+// against the gallery by its Fourier descriptors and the closest drawings are offered
+// one after the other until the user says yes.
+type GuessGame struct {
+    open        bool
+    ranking     []GuessMatch
+    index       int
+    answered    bool
+    message     string
+    // rounds counts the sketches guessed, right those guessed at the first try.
+    rounds      int
+    right       int
+}
+
+func pointDescriptors(points []Point) (descriptors.Descriptors, error) {
+    contour := make([]complex128, len(points))
+    for i, p := range points {
+        contour[i] = complex(p.x, p.y)
+    }
+    return descriptors.Compute(contour, descriptors.DEFAULT_SAMPLES, descriptors.DEFAULT_TERMS)
+}
+
+// rankGallery returns the gallery drawings closest to points first.
+func rankGallery(gallery []*GalleryEntry, points []Point) ([]GuessMatch, error) {
+    sketch, err := pointDescriptors(points)
+    if err != nil {
+        return nil, err
+    }
+    var ranking []GuessMatch
+    for _, entry := range gallery {
+        shape, err := pointDescriptors(entry.points)
+        if err != nil {
+            continue
+        }
+        ranking = append(ranking, GuessMatch{entry: entry, distance: descriptors.Distance(sketch, shape)})
+    }
+    sort.SliceStable(ranking, func(i, j int) bool {
+        return ranking[i].distance < ranking[j].distance
+    })
+    return ranking, nil
+}
+
+func (g *Game) startGuess() {
+    gs := &g.guess
+    gs.index = 0
+    gs.answered = false
+    gs.message = ""
+    ranking, err := rankGallery(g.gallery, g.points)
+    switch {
+    case err != nil:
+        gs.message = "Draw a shape first"
+        gs.ranking = nil
+    case len(ranking) == 0:
+        gs.message = "The gallery is empty"
+        gs.ranking = nil
+    default:
+        gs.ranking = ranking
+        gs.rounds++
+    }
+    g.setGuessOpen(true)
+}
+
+// answerGuess takes the user's answer to the current guess.
+func (g *Game) answerGuess(yes bool) {
+    gs := &g.guess
+    if (yes) {
+        if (gs.index == 0) {
+            gs.right++
+        }
+        gs.answered = true
+        gs.message = fmt.Sprintf("Got it: %s!", gs.ranking[gs.index].entry.name)
+    } else if (gs.index+1 >= min(GUESS_TRIES, len(gs.ranking))) {
+        gs.answered = true
+        gs.message = "I give up - draw it again?"
+    } else {
+        gs.index++
+    }
+    g.showGuess()
+}
+
+func (g *Game) guessPending() bool {
+    return g.guess.ranking != nil && !g.guess.answered
+}
+
+// showGuess puts the thumbnail of the current guess on its button.
+func (g *Game) showGuess() {
+    thumbnail := g.widgets.Get("guess-thumbnail").(*ui.Button)
+    thumbnail.Image, thumbnail.Label = nil, ""
+    if (g.guessPending()) {
+        match := g.guess.ranking[g.guess.index]
+        thumbnail.Image = match.entry.thumbnail
+        thumbnail.Label = fmt.Sprintf("%s (distance %.2f)", match.entry.name, match.distance)
+    }
+}
+
+func (g *Game) setGuessOpen(open bool) {
+    g.guess.open = open
+    g.widgets.Get("guess-panel").(*ui.Panel).Hidden = !open
+    g.showGuess()
+}
+
+func (g *Game) guessLabel() string {
+    gs := &g.guess
+    switch {
+    case gs.message != "":
+        return gs.message
+    case gs.index == 0:
+        return "Is it this one?"
+    }
+    return "Then is it this one?"
+}
+
+func (g *Game) guessScore() string {
+    return fmt.Sprintf("First-try guesses: %d of %d", g.guess.right, g.guess.rounds)
+}
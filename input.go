@@ -0,0 +1,212 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// InputFrame is everything the game reads from the user in one tick.
+type InputFrame struct {
+    Pointer PointerFrame    `json:"pointer"`
+    // Keys are the keys held down during the tick.
+    Keys    []ebiten.Key    `json:"keys,omitempty"`
+    // Chars is the text typed during the tick.
+    Chars   string          `json:"chars,omitempty"`
+}
+
+// InputSource produces one InputFrame per tick. Update reads the user only through
+// it, so a session can be scripted or replayed from a file tick for tick.
+type InputSource interface {
+    // NextInput returns the input of the next tick, and false once the source
+    // has nothing more to give.
+    NextInput() (InputFrame, bool)
+}
+
+// LiveInput reads the devices through Ebiten.
+type LiveInput struct {
+    pointers    EbitenPointers
+    keys        []ebiten.Key
+    chars       []rune
+}
+
+func (l *LiveInput) NextInput() (InputFrame, bool) {
+    l.keys = inpututil.AppendPressedKeys(l.keys[:0])
+    l.chars = ebiten.AppendInputChars(l.chars[:0])
+    return InputFrame{
+        Pointer:    l.pointers.NextFrame(),
+        Keys:       append([]ebiten.Key(nil), l.keys...),
+        Chars:      string(l.chars),
+    }, true
+}
+
+// ScriptedInput plays back frames pushed by code, one per tick.
+type ScriptedInput struct {
+    queue   []InputFrame
+}
+
+func (s *ScriptedInput) Push(frames ...InputFrame) {
+    s.queue = append(s.queue, frames...)
+}
+
+func (s *ScriptedInput) NextInput() (InputFrame, bool) {
+    if len(s.queue) == 0 {
+        return InputFrame{}, false
+    }
+    frame := s.queue[0]
+    s.queue = s.queue[1:]
+    return frame, true
+}
+
+// FileInput replays a session saved by InputRecorder: one JSON InputFrame per line.
+type FileInput struct {
+    file    *os.File
+    decoder *json.Decoder
+}
+
+func NewFileInput(filePath string) (*FileInput, error) {
+    file, err := os.Open(filePath)
+    if err != nil {
+        return nil, err
+    }
+    return &FileInput{file: file, decoder: json.NewDecoder(bufio.NewReader(file))}, nil
+}
+
+func (f *FileInput) NextInput() (InputFrame, bool) {
+    var frame InputFrame
+    if f.file == nil {
+        return frame, false
+    }
+    if err := f.decoder.Decode(&frame); err != nil {
+        if err != io.EOF {
+            fmt.Printf("Input replay stopped: %v\n", err)
+        }
+        f.file.Close()
+        f.file = nil
+        return InputFrame{}, false
+    }
+    return frame, true
+}
+
+// InputRecorder passes the frames of another source through, saving each of them.
+type InputRecorder struct {
+    source  InputSource
+    file    *os.File
+    w       *bufio.Writer
+    encoder *json.Encoder
+}
+
+func NewInputRecorder(source InputSource, filePath string) (*InputRecorder, error) {
+    file, err := os.Create(filePath)
+    if err != nil {
+        return nil, err
+    }
+    w := bufio.NewWriter(file)
+    return &InputRecorder{source: source, file: file, w: w, encoder: json.NewEncoder(w)}, nil
+}
+
+func (r *InputRecorder) NextInput() (InputFrame, bool) {
+    frame, ok := r.source.NextInput()
+    if ok && r.file != nil {
+        if err := r.encoder.Encode(frame); err != nil {
+            fmt.Printf("Input recording stopped: %v\n", err)
+            r.Close()
+        }
+    }
+    return frame, ok
+}
+
+func (r *InputRecorder) Close() error {
+    if r.file == nil {
+        return nil
+    }
+    err := r.w.Flush()
+    if closeErr := r.file.Close(); err == nil {
+        err = closeErr
+    }
+    r.file = nil
+    return err
+}
+
+// InputState is the input of the current tick along with what Update derives from
+// the previous ones: pointer edges and how long each key has been held.
+type InputState struct {
+    pointer         PointerFrame
+    previousPointer PointerFrame
+    chars           string
+    durations       map[ebiten.Key]int
+}
+
+func (s *InputState) advance(frame InputFrame) {
+    if s.durations == nil {
+        s.durations = make(map[ebiten.Key]int)
+    }
+    s.previousPointer = s.pointer
+    s.pointer = frame.Pointer
+    s.chars = frame.Chars
+
+    held := make(map[ebiten.Key]bool, len(frame.Keys))
+    for _, key := range frame.Keys {
+        held[key] = true
+        s.durations[key]++
+    }
+    for key := range s.durations {
+        if !held[key] {
+            delete(s.durations, key)
+        }
+    }
+}
+
+func (s *InputState) KeyPressed(key ebiten.Key) bool {
+    return s.durations[key] > 0
+}
+
+func (s *InputState) KeyJustPressed(key ebiten.Key) bool {
+    return s.durations[key] == 1
+}
+
+// RepeatingKeyPressed is true when key goes down and then, while it is held, at
+// the usual key repeat rate.
+func (s *InputState) RepeatingKeyPressed(key ebiten.Key) bool {
+    const (
+        delay = 30
+        interval = 3
+    )
+    d := s.durations[key]
+    return d == 1 || (d >= delay && (d-delay)%interval == 0)
+}
+
+func (s *InputState) PointerJustPressed() bool {
+    return s.pointer.Pressed() && !s.previousPointer.Pressed()
+}
+
+func (s *InputState) PointerJustReleased() bool {
+    return !s.pointer.Pressed() && s.previousPointer.Pressed()
+}
+
+// readInput advances the input by one tick. When a replay runs out the game goes
+// back to live input, or quits with -replay-exit.
+func (g *Game) readInput() error {
+    frame, ok := g.inputSource.NextInput()
+    if !ok {
+        if g.options.replayExit {
+            g.setState(END)
+            return ebiten.Termination
+        }
+        fmt.Printf("Input replay finished\n")
+        if (g.inputRecorder != nil) {
+            g.inputRecorder.source = &LiveInput{}
+        } else {
+            g.inputSource = &LiveInput{}
+        }
+        frame, _ = g.inputSource.NextInput()
+    }
+    g.ticks++
+    g.input.advance(frame)
+    return nil
+}
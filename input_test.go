@@ -0,0 +1,80 @@
+package main
+
+import (
+    "testing"
+
+    "github.com/hajimehoshi/ebiten/v2"
+)
+
+// newScriptedGame returns a game on the drawing board, without widgets, that reads
+// its input from the returned script. Tests push a frame for every Update they run,
+// or the game falls back to live input.
+func newScriptedGame(t *testing.T) (*Game, *ScriptedInput) {
+    script := &ScriptedInput{}
+    g := &Game{}
+    g.machine = g.newStateMachine()
+    g.machine.Logf = t.Logf
+    g.options = Options{width: 1920, height: 1080, speed: 1}
+    g.windowSize = struct{ width, height int }{1920, 1080}
+    g.camera = newCamera(1920, 1080)
+    g.keymap = defaultKeymap()
+    g.inputSource = script
+    if (!g.setState(DRAWING)) {
+        t.Fatal("cannot reach DRAWING")
+    }
+    return g, script
+}
+
+// stroke returns the frames of a drag through points, then a release.
+func stroke(points ...[2]float64) []InputFrame {
+    var frames []InputFrame
+    for _, p := range points {
+        frames = append(frames, InputFrame{Pointer: PointerFrame{X: p[0], Y: p[1], Contacts: []Pointer{{X: p[0], Y: p[1]}}}})
+    }
+    return append(frames, InputFrame{})
+}
+
+func press(key ebiten.Key) []InputFrame {
+    return []InputFrame{{Keys: []ebiten.Key{key}}, {}}
+}
+
+func TestEnterRunsTheDrawing(t *testing.T) {
+    g, script := newScriptedGame(t)
+    script.Push(stroke([2]float64{800, 400}, [2]float64{1000, 400}, [2]float64{1000, 600}, [2]float64{800, 600})...)
+    script.Push(press(ebiten.KeyEnter)...)
+    script.Push(make([]InputFrame, 40)...)
+
+    var states []GameState
+    for i := 0; i < 40 && g.state != FOURIER; i++ {
+        if err := g.Update(); err != nil {
+            t.Fatal(err)
+        }
+        if (len(states) == 0 || states[len(states)-1] != g.state) {
+            states = append(states, g.state)
+        }
+    }
+    if (len(g.points) != 4) {
+        t.Fatalf("the stroke drew %d points, want 4", len(g.points))
+    }
+    want := []GameState{DRAWING, REVEALING, COMPUTING, FOURIER}
+    if (len(states) != len(want)) {
+        t.Fatalf("went through %v, want %v", states, want)
+    }
+    for i := range want {
+        if (states[i] != want[i]) {
+            t.Fatalf("went through %v, want %v", states, want)
+        }
+    }
+}
+
+func TestEnterNeedsADrawing(t *testing.T) {
+    g, script := newScriptedGame(t)
+    script.Push(press(ebiten.KeyEnter)...)
+    script.Push(make([]InputFrame, 2)...)
+    for i := 0; i < 4; i++ {
+        g.Update()
+    }
+    if (g.state != DRAWING) {
+        t.Errorf("Enter on an empty board went to %v", g.state)
+    }
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/ui"
+)
+
+type Action int
+const (
+    TOGGLE_DOTS_ACTION Action = iota
+    TOGGLE_EPICYCLES_ACTION
+    SKIP_REVEAL_ACTION
+    BACK_ACTION
+    NEXT_THEME_ACTION
+    NEXT_TRAIL_ACTION
+    FOLLOW_TIP_ACTION
+    RESET_CAMERA_ACTION
+    TOGGLE_MAGNIFIER_ACTION
+    RECORD_ACTION
+    SPECTRUM_ACTION
+    MORPH_MODE_ACTION
+    EDIT_ACTION
+    SELECT_ALL_ACTION
+    DELETE_POINTS_ACTION
+    INSERT_POINT_ACTION
+    ROTATE_LEFT_ACTION
+    ROTATE_RIGHT_ACTION
+    SCALE_UP_ACTION
+    SCALE_DOWN_ACTION
+    LEAKAGE_ACTION
+    GUESS_ACTION
+    RUN_ACTION
+    HELP_ACTION
+)
+
+// ActionInfo describes an action: the name used in the keymap file, the text shown
+// in the help overlay, the states in which it is active and its default keys.
+type ActionInfo struct {
+    name        string
+    description string
+    states      []GameState
+    defaultKeys []ebiten.Key
+}
+
+var actionInfos = map[Action]ActionInfo{
+    TOGGLE_DOTS_ACTION:         {"toggle-dots", "Toggle the points visualization", []GameState{DRAWING, REVEALING, FOURIER, MORPHING}, []ebiten.Key{ebiten.KeyP}},
+    TOGGLE_EPICYCLES_ACTION:    {"toggle-epicycles", "Toggle the epicycles visualization", []GameState{DRAWING, REVEALING, FOURIER, MORPHING}, []ebiten.Key{ebiten.KeyE}},
+    SKIP_REVEAL_ACTION:         {"skip-reveal", "Skip the reveal animation", []GameState{REVEALING}, []ebiten.Key{ebiten.KeyS}},
+    BACK_ACTION:                {"back", "Go back to the drawing board", []GameState{REVEALING, FOURIER, GALLERY, EDITING, MORPHING}, []ebiten.Key{ebiten.KeyEscape}},
+    NEXT_THEME_ACTION:          {"next-theme", "Switch to the next color theme", []GameState{START, DRAWING, REVEALING, FOURIER, GALLERY, MORPHING}, []ebiten.Key{ebiten.KeyT}},
+    NEXT_TRAIL_ACTION:          {"next-trail", "Switch to the next trail style", []GameState{DRAWING, REVEALING, FOURIER, MORPHING}, []ebiten.Key{ebiten.KeyL}},
+    FOLLOW_TIP_ACTION:          {"follow-tip", "Keep the tip of the chain centred", []GameState{DRAWING, REVEALING, FOURIER, MORPHING}, []ebiten.Key{ebiten.KeyF}},
+    RESET_CAMERA_ACTION:        {"reset-camera", "Reset zoom and pan", []GameState{DRAWING, REVEALING, FOURIER, EDITING, MORPHING}, []ebiten.Key{ebiten.KeyHome, ebiten.KeyDigit0}},
+    TOGGLE_MAGNIFIER_ACTION:    {"toggle-magnifier", "Show or hide the magnified view of a chain tip", []GameState{FOURIER}, []ebiten.Key{ebiten.KeyM}},
+    RECORD_ACTION:              {"record", "Record the run from the start, again to stop", []GameState{FOURIER}, []ebiten.Key{ebiten.KeyR}},
+    SPECTRUM_ACTION:            {"spectrum", "Show or hide the spectrum editor, which keeps the run looping", []GameState{FOURIER}, []ebiten.Key{ebiten.KeyK}},
+    MORPH_MODE_ACTION:          {"morph-mode", "Switch between linear and polar blending", []GameState{MORPHING}, []ebiten.Key{ebiten.KeyB}},
+    EDIT_ACTION:                {"edit", "Edit the points of the drawing, again to stop", []GameState{DRAWING, EDITING}, []ebiten.Key{ebiten.KeyV}},
+    SELECT_ALL_ACTION:          {"select-all", "Select every point", []GameState{EDITING}, []ebiten.Key{ebiten.KeyA}},
+    DELETE_POINTS_ACTION:       {"delete-points", "Delete the selected points", []GameState{EDITING}, []ebiten.Key{ebiten.KeyDelete, ebiten.KeyBackspace}},
+    INSERT_POINT_ACTION:        {"insert-point", "Insert a point at the cursor into the closest segment", []GameState{EDITING}, []ebiten.Key{ebiten.KeyI, ebiten.KeyInsert}},
+    ROTATE_LEFT_ACTION:         {"rotate-left", "Rotate the selection counterclockwise", []GameState{EDITING}, []ebiten.Key{ebiten.KeyQ}},
+    ROTATE_RIGHT_ACTION:        {"rotate-right", "Rotate the selection clockwise", []GameState{EDITING}, []ebiten.Key{ebiten.KeyW}},
+    SCALE_UP_ACTION:            {"scale-up", "Enlarge the selection", []GameState{EDITING}, []ebiten.Key{ebiten.KeyEqual}},
+    SCALE_DOWN_ACTION:          {"scale-down", "Shrink the selection", []GameState{EDITING}, []ebiten.Key{ebiten.KeyMinus}},
+    LEAKAGE_ACTION:             {"leakage", "Show or hide the spectrum with and without a window", []GameState{DRAWING}, []ebiten.Key{ebiten.KeyW}},
+    GUESS_ACTION:               {"guess", "Guess which gallery drawing the sketch is", []GameState{DRAWING}, []ebiten.Key{ebiten.KeyG}},
+    RUN_ACTION:                 {"run", "Run the epicycles of the drawing", []GameState{DRAWING}, []ebiten.Key{ebiten.KeyEnter}},
+    HELP_ACTION:                {"help", "Show or hide this help", []GameState{START, DRAWING, REVEALING, FOURIER, GALLERY, EDITING, MORPHING}, []ebiten.Key{ebiten.KeyH, ebiten.KeySlash}},
+}
+
+// actionOrder is the order in which actions are listed in the help overlay.
+var actionOrder = []Action{
+    TOGGLE_DOTS_ACTION,
+    TOGGLE_EPICYCLES_ACTION,
+    SKIP_REVEAL_ACTION,
+    BACK_ACTION,
+    NEXT_THEME_ACTION,
+    NEXT_TRAIL_ACTION,
+    FOLLOW_TIP_ACTION,
+    RESET_CAMERA_ACTION,
+    TOGGLE_MAGNIFIER_ACTION,
+    RECORD_ACTION,
+    SPECTRUM_ACTION,
+    MORPH_MODE_ACTION,
+    EDIT_ACTION,
+    SELECT_ALL_ACTION,
+    DELETE_POINTS_ACTION,
+    INSERT_POINT_ACTION,
+    ROTATE_LEFT_ACTION,
+    ROTATE_RIGHT_ACTION,
+    SCALE_UP_ACTION,
+    SCALE_DOWN_ACTION,
+    LEAKAGE_ACTION,
+    GUESS_ACTION,
+    RUN_ACTION,
+    HELP_ACTION,
+}
+
+// Keymap binds actions to keys. Actions are edge-triggered: they fire once on the
+// tick the key goes down, not on every tick it is held.
+type Keymap struct {
+    bindings map[Action][]ebiten.Key
+}
+
+func defaultKeymap() *Keymap {
+    km := &Keymap{bindings: make(map[Action][]ebiten.Key)}
+    for action, info := range actionInfos {
+        km.bindings[action] = info.defaultKeys
+    }
+    return km
+}
+
+func defaultKeymapPath() string {
+    dir, err := os.UserConfigDir()
+    if err != nil {
+        return ""
+    }
+    return filepath.Join(dir, "fourier-drawing", "keymap.json")
+}
+
+// loadKeymap reads a JSON object mapping action names to key name lists, e.g.
+// {"toggle-dots": ["D"], "help": ["H", "F1"]}, on top of the default bindings.
+// A missing file is not an error.
+func loadKeymap(filePath string) (*Keymap, error) {
+    km := defaultKeymap()
+    if filePath == "" {
+        return km, nil
+    }
+
+    data, err := os.ReadFile(filePath)
+    if os.IsNotExist(err) {
+        return km, nil
+    }
+    if err != nil {
+        return km, err
+    }
+
+    var config map[string][]ebiten.Key
+    if err := json.Unmarshal(data, &config); err != nil {
+        return km, fmt.Errorf("%s: %w", filePath, err)
+    }
+    for name, keys := range config {
+        action, ok := actionByName(name)
+        if !ok {
+            return km, fmt.Errorf("%s: unknown action %q", filePath, name)
+        }
+        km.bindings[action] = keys
+    }
+    return km, nil
+}
+
+func actionByName(name string) (Action, bool) {
+    for action, info := range actionInfos {
+        if info.name == name {
+            return action, true
+        }
+    }
+    return 0, false
+}
+
+func (info ActionInfo) activeIn(state GameState) bool {
+    for _, s := range info.states {
+        if s == state {
+            return true
+        }
+    }
+    return false
+}
+
+// Triggered reports whether one of the action keys went down this tick while the
+// action is active in state.
+func (km *Keymap) Triggered(action Action, state GameState, input *InputState) bool {
+    if !actionInfos[action].activeIn(state) {
+        return false
+    }
+    for _, key := range km.bindings[action] {
+        if input.KeyJustPressed(key) {
+            return true
+        }
+    }
+    return false
+}
+
+// KeyNames returns the keys bound to action, e.g. "H / Slash".
+func (km *Keymap) KeyNames(action Action) string {
+    names := make([]string, len(km.bindings[action]))
+    for i, key := range km.bindings[action] {
+        names[i] = key.String()
+    }
+    return strings.Join(names, " / ")
+}
+
+func (g *Game) drawHelpOverlay(screen *ebiten.Image) {
+    var active []Action
+    for _, action := range actionOrder {
+        if actionInfos[action].activeIn(g.state) && len(g.keymap.bindings[action]) > 0 {
+            active = append(active, action)
+        }
+    }
+
+    width, height := 720.0, 70.0+float64(len(active))*28
+    x := (float64(g.windowSize.width)-width)/2
+    y := (float64(g.windowSize.height)-height)/2
+    background := g.theme.UIBackground
+    background.A = 230
+    vector.DrawFilledRect(screen, float32(x), float32(y), float32(width), float32(height), background, false)
+    vector.StrokeRect(screen, float32(x), float32(y), float32(width), float32(height), 2, g.style.Border, false)
+    ui.DrawText(screen, "Keyboard shortcuts", 24, x+20, y+15, g.style.Text)
+    for i, action := range active {
+        lineY := y+55+float64(i)*28
+        ui.DrawText(screen, g.keymap.KeyNames(action), ui.DEFAULT_TEXT_SIZE, x+20, lineY, g.style.Accent)
+        ui.DrawText(screen, actionInfos[action].description, ui.DEFAULT_TEXT_SIZE, x+220, lineY, g.style.Text)
+    }
+}
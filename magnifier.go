@@ -0,0 +1,80 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/ui"
+)
+
+const (
+    MAGNIFIER_SIZE = 360
+    MAGNIFIER_MARGIN = 20
+)
+
+type MagnifierTarget int
+const (
+    X_TIP_TARGET MagnifierTarget = iota
+    Y_TIP_TARGET
+    PEN_TARGET
+    MAGNIFIER_TARGETS
+)
+
+var magnifierTargetNames = [MAGNIFIER_TARGETS]string{"x tip", "y tip", "pen"}
+
+// Magnifier is an inset in the bottom right corner of FOURIER showing the scene
+// around a tip at a fixed magnification, whatever the main camera does. The scene
+// is drawn a second time into its own image, through its own camera.
+type Magnifier struct {
+    enabled bool
+    zoom    float64
+    target  MagnifierTarget
+    image   *ebiten.Image
+    camera  Camera
+}
+
+func newMagnifier(enabled bool, zoom float64) Magnifier {
+    m := Magnifier{enabled: enabled, zoom: zoom}
+    m.camera = newCamera(MAGNIFIER_SIZE, MAGNIFIER_SIZE)
+    return m
+}
+
+func (g *Game) setMagnifierTarget(target MagnifierTarget) {
+    g.magnifier.target = (target+MAGNIFIER_TARGETS)%MAGNIFIER_TARGETS
+    g.widgets.Get("magnifier-target").(*ui.Button).Label = "Magnify: " + magnifierTargetNames[g.magnifier.target]
+}
+
+// drawMagnifier renders the inset, given the board positions of the chain tips of
+// the frame just drawn.
+func (g *Game) drawMagnifier(screen *ebiten.Image, x1, y1, x2, y2 float64) {
+    m := &g.magnifier
+    if !m.enabled {
+        return
+    }
+    if m.image == nil {
+        m.image = ebiten.NewImage(MAGNIFIER_SIZE, MAGNIFIER_SIZE)
+    }
+
+    switch m.target {
+    case X_TIP_TARGET:
+        m.camera.x, m.camera.y = x1, y1
+    case Y_TIP_TARGET:
+        m.camera.x, m.camera.y = x2, y2
+    case PEN_TARGET:
+        m.camera.x, m.camera.y = x1, y2
+    }
+    m.camera.zoom = m.zoom
+
+    m.image.Fill(g.theme.Background)
+    g.drawFourierScene(m.image, &m.camera)
+
+    x := float64(g.windowSize.width-MAGNIFIER_SIZE-MAGNIFIER_MARGIN)
+    y := float64(g.windowSize.height-MAGNIFIER_SIZE-MAGNIFIER_MARGIN)
+    opts := &ebiten.DrawImageOptions{}
+    opts.GeoM.Translate(x, y)
+    screen.DrawImage(m.image, opts)
+    vector.StrokeRect(screen, float32(x), float32(y), MAGNIFIER_SIZE, MAGNIFIER_SIZE, 2, g.theme.UIBorder, false)
+    ui.DrawText(screen, fmt.Sprintf("%s  %.0fx", magnifierTargetNames[m.target], m.zoom), ui.DEFAULT_TEXT_SIZE, x+8, y+6, g.theme.Text)
+}
@@ -3,26 +3,22 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"image"
 	"image/color"
+	"io"
 	"log"
 	"math"
-	"math/cmplx"
 	"os"
 	"strconv"
 	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/text"
 	"github.com/hajimehoshi/ebiten/v2/vector"
-	"github.com/sqweek/dialog"
-
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/basicfont"
-	"golang.org/x/image/math/fixed"
 
+	"fourier-drawing/engine"
 	"fourier-drawing/fourier"
+	"fourier-drawing/statemachine"
+	"fourier-drawing/ui"
 )
 
 type GameState int
@@ -32,56 +28,101 @@ const (
     DRAWING
     REVEALING
     COMPUTING
-    PRERENDERING
     FOURIER
+    GALLERY
+    BROWSING
+    EDITING
+    MORPHING
     END
 )
 
-type Button struct {
-    x, y, width, height float64
-    text                []string
-    onClick             func(g *Game)
-    pressed             bool
-}
-
-type ButtonIndex int
-const (
-    START_BUTTON ButtonIndex = iota
-    CLEAR_BUTTON
-    SAVE_BUTTON
-    LOAD_BUTTON
-    FOURIER_BUTTON
-)
-
+// Point is a sample of a drawing. t is the drawing time in seconds since the first
+// sample, 0 everywhere for drawings saved without timing, and pressure the pen
+// pressure in [0, 1]. Ebiten reports no pressure for mice, touches or pens, so
+// captured points get 1 until it does; files can still carry real values.
 type Point struct {
-    x, y float64
+    x, y        float64
+    t           float64
+    pressure    float64
 }
 
-/* PRERENDERING DISABLED
-type Frame struct {
-    drawingLayer *ebiten.Image
-    dotsLayer *ebiten.Image
-    epicyclesLayer *ebiten.Image
-}
-*/
-
 // Required from Ebiten.
 // Game implements ebiten.Game interface.
 type Game struct {
     windowSize                  struct{ width, height int }
-    // prerenderedFrames           []Frame        
     // frame                       *ebiten.Image        
     points                      []Point
     state                       GameState
+    machine                     *statemachine.Machine[GameState]
     revealIndex                 int
-    prerenderIndex              int
     toggleDots                  bool
     toggleEpicycles             bool
-    fourierX                    []fourier.FourierElement
-    fourierY                    []fourier.FourierElement
+    model                       *engine.Model
+    // unfilteredPath is the reconstruction without the filter, for comparison.
+    unfilteredPath              []engine.Vec
     fourierIndex                int
-    fourierPoints               []Point
-    buttons                     []*Button
+    fourierTime                 float64
+    widgets                     *ui.Registry
+    style                       ui.Style
+    gallery                     []*GalleryEntry
+    galleryScroll               int
+    fileBrowser                 *FileBrowser
+    options                     Options
+    keymap                      *Keymap
+    showHelp                    bool
+    themes                      []*Theme
+    themeIndex                  int
+    theme                       *Theme
+    trailStyle                  TrailStyle
+    epicycleStyle               EpicycleStyle
+    camera                      Camera
+    magnifier                   Magnifier
+    editor                      Editor
+    spectrum                    SpectrumEditor
+    leakage                     LeakageView
+    guess                       GuessGame
+    morph                       *Morph
+    playlist                    *Playlist
+    recorder                    *Recorder
+    inputSource                 InputSource
+    inputRecorder               *InputRecorder
+    input                       InputState
+    ticks                       int
+    lastCaptureTick             int
+}
+
+// computeModel transforms the points around the window centre through window, filters
+// the spectra when filter is active and keeps the maxEpicycles largest terms of each axis (all of
+// them when maxEpicycles is 0). With byTime, timed drawings are first resampled at even
+// time steps, so playback follows the rhythm they were drawn with instead of moving one
+// point per step.
+func computeModel(points []Point, width, height, maxEpicycles int, byTime bool, filter *SpectrumFilter, window *SpectrumWindow) *engine.Model {
+    if (byTime) {
+        points = resampleByTime(points)
+    }
+    pointsLen := len(points)
+    sequenceX := make([]float64, pointsLen)
+    sequenceY := make([]float64, pointsLen)
+    for i:=0; i<pointsLen; i++ {
+        sequenceX[i] = points[i].x
+        sequenceY[i] = points[i].y
+    }
+    shiftSequence(sequenceX, float64(-width)/2)
+    shiftSequence(sequenceY, float64(-height)/2)
+    fourierX := fourier.KeepLargest(filter.apply(windowedTransform(sequenceX, window.function())), maxEpicycles)
+    fourierY := fourier.KeepLargest(filter.apply(windowedTransform(sequenceY, window.function())), maxEpicycles)
+    return newBoardModel(fourierX, fourierY, width, height)
+}
+
+// newBoardModel lays out the chains of spectra taken around the centre of a width by
+// height board: the x chain hangs from the top edge and the y chain, turned a quarter,
+// from the left one.
+func newBoardModel(fourierX, fourierY []fourier.FourierElement, width, height int) *engine.Model {
+    return engine.NewModel(
+        engine.Axis{Terms: fourierX, Origin: engine.Vec{X: float64(width)/2, Y: 100}},
+        engine.Axis{Terms: fourierY, Origin: engine.Vec{X: 200, Y: float64(height)/2}, Phase: -math.Pi/2},
+        engine.Vec{X: float64(width)/2, Y: float64(height)/2},
+    )
 }
 
 const BUFFER_CIRCLES_OPTIONS = 10;
@@ -92,20 +133,22 @@ var BufferCircles [BUFFER_CIRCLES_OPTIONS]struct {
     radius      float64
 }
 
-func writePointsToFile(points []Point) error {
-    filePath, err := dialog.File().Filter("Text files (*.txt)", "txt").Load()
-    if err != nil {
-        return err
-    }
-
+func writePointsToFile(filePath string, points []Point) error {
     file, err := os.Create(filePath)
     if err != nil {
         return err
     }
     defer file.Close()
 
+    // Drawings without timing keep the plain "x, y" format older versions read.
+    timed := hasTiming(points)
     for _, point := range points {
-        _, err := fmt.Fprintf(file, "%f, %f\n", point.x, point.y)
+        var err error
+        if (timed) {
+            _, err = fmt.Fprintf(file, "%f, %f, %f, %f\n", point.x, point.y, point.t, point.pressure)
+        } else {
+            _, err = fmt.Fprintf(file, "%f, %f\n", point.x, point.y)
+        }
         if err != nil {
             return err
         }
@@ -114,41 +157,42 @@ func writePointsToFile(points []Point) error {
     return nil
 }
 
-func readPointsFromFile() ([]Point) {
-    filePath, err := dialog.File().Filter("Text files (*.txt)", "txt").Load()
-    if err != nil {
-        return nil
-    }
-
+func readPointsFromFile(filePath string) ([]Point, error) {
     file, err := os.Open(filePath)
     if err != nil {
-        return nil
+        return nil, err
     }
     defer file.Close()
 
+    return parsePoints(file)
+}
+
+// parsePoints reads one point per line: "x, y" or "x, y, t, pressure", where t and
+// pressure may be left out from the end. Other lines are skipped.
+func parsePoints(r io.Reader) ([]Point, error) {
     var points []Point
-    scanner := bufio.NewScanner(file)
+    scanner := bufio.NewScanner(r)
 
     for scanner.Scan() {
         line := scanner.Text()
         parts := strings.Split(line, ",")
-        if len(parts) != 2 {
+        if len(parts) < 2 || len(parts) > 4 {
             continue
-                }
-        x, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
-        if err != nil {
-            return nil
         }
-        y, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
-        if err != nil {
-            return nil
+        values := []float64{0, 0, 0, 1}
+        for i, part := range parts {
+            value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+            if err != nil {
+                return nil, err
+            }
+            values[i] = value
         }
-        points = append(points, Point{x, y})
+        points = append(points, Point{x: values[0], y: values[1], t: values[2], pressure: values[3]})
     }
     if err := scanner.Err(); err != nil {
-        return nil
+        return nil, err
     }
-    return points
+    return points, nil
 }
 
 func shiftSequence(sequence []float64, shift float64) {
@@ -157,34 +201,6 @@ func shiftSequence(sequence []float64, shift float64) {
     }
 }
 
-func drawButton(screen *ebiten.Image, button *Button) {
-    borderColor := color.RGBA{255, 255, 255, 255}
-    borderWidth := 5.0
-    ebitenutil.DrawRect(screen, button.x-borderWidth, button.y-borderWidth, button.width+2*borderWidth, button.height+2*borderWidth, borderColor)
-
-    buttonColor := color.RGBA{0, 0, 0, 255}
-    ebitenutil.DrawRect(screen, button.x, button.y, button.width, button.height, buttonColor)
-
-    fontFace := basicfont.Face7x13
-    textHeight := 13
-
-    textX := int(button.x)+20
-    textY := int(button.y)+20
-
-    d := &font.Drawer{
-        Dst:  screen,
-        Src:  image.NewUniform(color.White),
-        Face: fontFace,
-        Dot:  fixed.P(textX, textY),
-    }
-
-    for i:=0; i<len(button.text); i++ {
-        d.DrawString(button.text[i])
-        textY += textHeight
-        d.Dot = fixed.P(textX, textY)
-    }
-}
-
 func drawAndInitBufferCircles() {
 	for i:=0; i<BUFFER_CIRCLES_OPTIONS; i++ {
 		BufferCircles[i].cx = 50.0*float64(i+1)
@@ -193,18 +209,24 @@ func drawAndInitBufferCircles() {
 		BufferCircles[i].circle = ebiten.NewImage(int(BufferCircles[i].cx)*2, int(BufferCircles[i].cy)*2)
 		steps := 100
 		dAngle := 2*math.Pi/float64(steps)
-		point1 := Point{BufferCircles[i].cx+BufferCircles[i].radius, BufferCircles[i].cy}
-		point2 := Point{0, 0}
+		point1 := Point{x: BufferCircles[i].cx+BufferCircles[i].radius, y: BufferCircles[i].cy}
+		point2 := Point{}
 		for j:=1; j<=steps; j++ {
 			point2.x = BufferCircles[i].cx+BufferCircles[i].radius*math.Cos(dAngle*float64(j))
 			point2.y = BufferCircles[i].cy+BufferCircles[i].radius*math.Sin(dAngle*float64(j))
-			ebitenutil.DrawLine(BufferCircles[i].circle, point1.x, point1.y, point2.x, point2.y, color.RGBA{150, 150, 150, 255})
+			ebitenutil.DrawLine(BufferCircles[i].circle, point1.x, point1.y, point2.x, point2.y, color.White)
 			point1 = point2
 		}
 	}
 }
 
-func drawEmptyCircle(screen *ebiten.Image, cx, cy, radius float64) {
+// drawEmptyCircle scales the closest buffer circle, drawn in white, and tints it with circleColor.
+// Circles larger than every buffer circle, as seen when zoomed in, are stroked instead.
+func drawEmptyCircle(screen *ebiten.Image, cx, cy, radius float64, circleColor color.Color) {
+    if (radius > BufferCircles[BUFFER_CIRCLES_OPTIONS-1].radius*1.5) {
+        vector.StrokeCircle(screen, float32(cx), float32(cy), float32(radius), 1, circleColor, true)
+        return
+    }
     index := 0
 	for i:=0; i<BUFFER_CIRCLES_OPTIONS; i++ {
 		if math.Abs(radius-BufferCircles[i].radius) < math.Abs(radius-BufferCircles[index].radius) {
@@ -214,13 +236,14 @@ func drawEmptyCircle(screen *ebiten.Image, cx, cy, radius float64) {
 	opts := &ebiten.DrawImageOptions{}
 	opts.GeoM.Scale(radius/BufferCircles[index].radius, radius/BufferCircles[index].radius)
 	opts.GeoM.Translate(cx-BufferCircles[index].cx*radius/BufferCircles[index].radius, cy-BufferCircles[index].cy*radius/BufferCircles[index].radius)
-    
+	opts.ColorScale.ScaleWithColor(circleColor)
+
 	screen.DrawImage(BufferCircles[index].circle, opts)
 }
 
-func drawEmptyCircleWithRadius(screen1 *ebiten.Image, screen2 *ebiten.Image, cx, cy, radius, angle float64, lineColor color.Color, drawCircle bool) (x, y float64) {
+func drawEmptyCircleWithRadius(screen1 *ebiten.Image, screen2 *ebiten.Image, cx, cy, radius, angle float64, lineColor, circleColor color.Color, drawCircle bool) (x, y float64) {
     if (drawCircle) {
-		drawEmptyCircle(screen2, cx, cy, radius)
+		drawEmptyCircle(screen2, cx, cy, radius, circleColor)
 	}
     x = cx+radius*math.Cos(angle)
     y = cy-radius*math.Sin(angle)
@@ -229,266 +252,174 @@ func drawEmptyCircleWithRadius(screen1 *ebiten.Image, screen2 *ebiten.Image, cx,
     return x,y
 }
 
-func drawFourierEpicycles(screen1 *ebiten.Image, screen2 *ebiten.Image, fourierSeq []fourier.FourierElement, fourierInd int, startX, startY, phase float64, drawCircles bool) (x, y float64) {
-    N := len(fourierSeq)
-    x, y = startX, startY
-
-    for k:=0; k<N; k++ {
-        radius := cmplx.Abs(fourierSeq[k].Val)/float64(N)
-        arg := 2 * math.Pi * float64(fourierInd) * float64(fourierSeq[k].Freq) / float64(N) + cmplx.Phase(fourierSeq[k].Val) + phase;
-
-        x, y = drawEmptyCircleWithRadius(screen1, screen2, x, y, radius, arg, color.RGBA{150, 150, 150, 255}, drawCircles)
-    }
-
-    return x, y
-}
+// drawFourierEpicycles draws chain through camera, the radii onto screen1 and the
+// circles onto screen2.
+func drawFourierEpicycles(screen1 *ebiten.Image, screen2 *ebiten.Image, chain *engine.Chain, drawCircles bool, theme *Theme, style *EpicycleStyle, camera *Camera) {
+    for _, e := range chain.Epicycles {
+        // The threshold is in screen pixels, so zooming in brings small terms back.
+        screenRadius := e.Radius*camera.zoom
+        sx, sy := camera.ToScreen(e.Center.X, e.Center.Y)
+        if (screenRadius < style.minRadius || !camera.Visible(sx, sy, screenRadius)) {
+            continue
+        }
 
-func (b *Button) CheckIfClicked(g *Game) (pressed bool) {
-    pressed = false
-    if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-        tempX, tempY := ebiten.CursorPosition()
-        mouseX, mouseY := float64(tempX), float64(tempY)
-        if mouseX>=b.x && mouseX<=b.x+b.width && mouseY>=b.y && mouseY<=b.y+b.height {
-            b.pressed = true
-            pressed = true
+        radiusColor, circleColor := style.epicycleColors(theme, e.Freq, chain.N, e.Radius, chain.MaxRadius)
+        if (drawCircles && style.filledDiscs) {
+            drawFilledDisc(screen2, sx, sy, screenRadius, circleColor)
         }
-    } else if b.pressed {
-        tempX, tempY := ebiten.CursorPosition()
-        mouseX, mouseY := float64(tempX), float64(tempY)
-        if mouseX>=b.x && mouseX<=b.x+b.width && mouseY>=b.y && mouseY<=b.y+b.height {
-            (*b).onClick(g)
+        ex, ey := drawEmptyCircleWithRadius(screen1, screen2, sx, sy, screenRadius, e.Angle, radiusColor, circleColor, drawCircles)
+        if (style.arrows) {
+            drawArrowhead(screen1, sx, sy, ex, ey, radiusColor)
         }
-        b.pressed = false
-        pressed = true
     }
-    return pressed
 }
 
-/* PRERENDERING DISABLED
-func preRenderFrame(g *Game, frameIndex int) Frame {
-    color1 := color.RGBA{64, 64, 64, 64}
-    color3 := color.RGBA{255, 255, 255, 255}
-
-    circleWidthBold := 4.0
-
-    drawingImage := ebiten.NewImage(g.windowSize.width, g.windowSize.height)
-    dotsImage := ebiten.NewImage(g.windowSize.width, g.windowSize.height)
-    epicyclesImage := ebiten.NewImage(g.windowSize.width, g.windowSize.height)
-    
-    x1, y1 := drawFourierEpicycles(drawingImage, epicyclesImage, g.fourierX, frameIndex, float64(g.windowSize.width)/2 , 100, 0.0, g.toggleEpicycles)
-    x2, y2 := drawFourierEpicycles(drawingImage, epicyclesImage, g.fourierY, frameIndex, 200, float64(g.windowSize.height)/2, -math.Pi/2, g.toggleEpicycles)
-
-    vector.DrawFilledCircle(drawingImage, float32(x1), float32(y1), float32(6.0), color.RGBA{255, 0, 0, 100}, false)
-    vector.DrawFilledCircle(drawingImage, float32(x2), float32(y2), float32(6.0), color.RGBA{0, 255, 0, 100}, false)
-    
-    if (y2 >= 200) {
-        ebitenutil.DrawLine(drawingImage, x1, y1, x1, float64(g.windowSize.height), color.White)
-    } else {
-        ebitenutil.DrawLine(drawingImage, x1, 0, x1, y1, color.White)
-    }
-    if (x1 >= 200) {
-        ebitenutil.DrawLine(drawingImage, x2, y2, float64(g.windowSize.width), y2, color.White)
-    } else {
-        ebitenutil.DrawLine(drawingImage, 0, y2, x2, y2, color.White)
-    }
-
-    for i:=1; i<frameIndex; i++ {
-        ebitenutil.DrawLine(drawingImage, g.fourierPoints[i-1].x, g.fourierPoints[i-1].y, g.fourierPoints[i].x, g.fourierPoints[i].y, color1)
-        ebitenutil.DrawCircle(dotsImage, g.fourierPoints[i].x, g.fourierPoints[i].y, circleWidthBold, color3)
-    }
-
-    return Frame{ drawingLayer: drawingImage, dotsLayer: dotsImage, epicyclesLayer: epicyclesImage }
-}
-
-func preRenderBatchOfFrames(g *Game) {
-    for i:=0; i<3 && g.prerenderIndex<len(g.fourierX); i++ {
-        g.prerenderedFrames[g.prerenderIndex] = preRenderFrame(g, g.prerenderIndex)
-        g.prerenderIndex++
-    }    
-}
-*/
-
 // Required from Ebiten.
 // Update proceeds the game state.
 // Update is called every tick (1/60 [s] by default).
 func (g *Game) Update() error {
-    if (ebiten.IsKeyPressed(ebiten.KeyC)) {
-        g.toggleDots = true
-    } else if (ebiten.IsKeyPressed(ebiten.KeyV)) {
-        g.toggleDots = false
-    } else if (ebiten.IsKeyPressed(ebiten.KeyD)) {
-        g.toggleEpicycles = true
-    } else if (ebiten.IsKeyPressed(ebiten.KeyF)) {
-        g.toggleEpicycles = false
+    if err := g.readInput(); err != nil {
+        return err
+    }
+    uiInput := g.readUIInput()
+    pointerCaptured := false
+    // A running playlist is a kiosk: no widgets to click, only the drawings.
+    if (g.widgets != nil && g.playlist == nil) {
+        pointerCaptured = g.widgets.Update(&uiInput, int(g.state))
+    }
+    if (g.playlist != nil && g.playlist.fadeTicks > 0) {
+        g.playlist.fadeTicks--
+    }
+
+    if (g.keymap.Triggered(HELP_ACTION, g.state, &g.input)) {
+        g.showHelp = !g.showHelp
+    }
+    if (g.keymap.Triggered(TOGGLE_DOTS_ACTION, g.state, &g.input)) {
+        g.toggleDots = !g.toggleDots
+    }
+    if (g.keymap.Triggered(TOGGLE_EPICYCLES_ACTION, g.state, &g.input)) {
+        g.toggleEpicycles = !g.toggleEpicycles
+    }
+    if (g.keymap.Triggered(NEXT_THEME_ACTION, g.state, &g.input)) {
+        g.setTheme(g.themeIndex+1)
+    }
+    if (g.keymap.Triggered(NEXT_TRAIL_ACTION, g.state, &g.input)) {
+        g.setTrailStyle(g.trailStyle+1)
+    }
+    if (g.keymap.Triggered(BACK_ACTION, g.state, &g.input)) {
+        g.setState(DRAWING)
+    }
+    if (g.keymap.Triggered(EDIT_ACTION, g.state, &g.input)) {
+        if (g.state == EDITING) {
+            g.setState(DRAWING)
+        } else {
+            g.setState(EDITING)
+        }
+    }
+    if (g.keymap.Triggered(FOLLOW_TIP_ACTION, g.state, &g.input)) {
+        g.camera.follow = !g.camera.follow
+    }
+    if (g.keymap.Triggered(TOGGLE_MAGNIFIER_ACTION, g.state, &g.input)) {
+        g.magnifier.enabled = !g.magnifier.enabled
+    }
+    if (g.keymap.Triggered(MORPH_MODE_ACTION, g.state, &g.input)) {
+        g.setMorphMode(g.options.morphMode+1)
+    }
+    if (g.keymap.Triggered(LEAKAGE_ACTION, g.state, &g.input)) {
+        g.setLeakageOpen(!g.leakage.open)
+    }
+    // Enter belongs to the focused widget, if any.
+    if (g.keymap.Triggered(RUN_ACTION, g.state, &g.input) && (g.widgets == nil || g.widgets.Focused() == nil)) {
+        g.setState(REVEALING)
+    }
+    if (g.keymap.Triggered(GUESS_ACTION, g.state, &g.input)) {
+        g.startGuess()
+    }
+    if (g.keymap.Triggered(SPECTRUM_ACTION, g.state, &g.input)) {
+        g.setSpectrumOpen(!g.spectrum.open)
+    }
+    if (g.keymap.Triggered(RECORD_ACTION, g.state, &g.input)) {
+        if (g.recorder == nil) {
+            g.startRecording()
+        } else {
+            g.stopRecording()
+        }
+    }
+    if (g.keymap.Triggered(RESET_CAMERA_ACTION, g.state, &g.input)) {
+        g.camera.Reset()
+        g.camera.follow = false
+    }
+    if (g.state == DRAWING || g.state == REVEALING || g.state == FOURIER || g.state == EDITING || g.state == MORPHING) {
+        g.camera.Update(g.input.pointer, g.input.previousPointer, pointerCaptured)
     }
 
     switch g.state {
     case PREPARING:
         drawAndInitBufferCircles()
-        g.buttons = append(g.buttons, &Button{775.0, 450.0, 350.0, 110.0,
-            []string{
-                "====== ========     ||      =====   ==========",
-                "||        ||       || ||    ||   ||     ||",
-                "||        ||      ||   ||   ||    ||    ||",
-                "======    ||     =========  ======      ||",
-                "     ||   ||    ||       || ||   ||     ||",
-                "     ||   ||    ||       || ||    ||    ||",
-                "======    ||    ||       || ||     ||   ||",
-            },
-            func (g *Game) {
-                g.state = DRAWING},
-            false,
-        })
-        g.buttons = append(g.buttons, &Button{1570.0, 10.0, 330.0, 110.0,
-            []string{
-                "======  ||     ======     ||      =====   ",
-                "||      ||     ||        || ||    ||   || ",
-                "||      ||     ||       ||   ||   ||    ||",
-                "||      ||     ======  =========  ======  ",
-                "||      ||     ||     ||       || ||   || ",
-                "||      ||     ||     ||       || ||    ||",
-                "======  ====== ====== ||       || ||     |",
-            },
-            func (g *Game) {
-                g.points = make([]Point, 0)
-                // g.prerenderedFrames = make([]Frame, 0)
-                g.prerenderIndex = 0
-            },
-            false,
-        })
-        g.buttons = append(g.buttons, &Button{10.0, 815.0, 320.0, 110.0,
-            []string{
-                "======      ||     ||          || ======",
-                "||         || ||    ||        ||  ||    ",
-                "||        ||   ||    ||      ||   ||    ",
-                "======   =========    ||    ||    ======",
-                "     || ||       ||    ||  ||     ||    ",
-                "     || ||       ||     ||||      ||    ",
-                "======  ||       ||      ||       ======",
-            },
-            func (g *Game) {
-                err := writePointsToFile(g.points)
-                if (err != nil) {
-                    fmt.Printf("Unable to write points to file.\n")
-                }
-            },
-            false,
-        })
-        g.buttons = append(g.buttons, &Button{10.0, 950.0, 295.0, 110.0,
-            []string{
-                "||      =======      ||      =====    ",
-                "||      |     |     || ||    ||   ||  ",
-                "||     ||     ||   ||   ||   ||    || ",
-                "||     ||     ||  =========  ||    || ",
-                "||     ||     || ||       || ||    || ",
-                "||      |     |  ||       || ||   ||  ",
-                "======  =======  ||       || =====    ",
-            },
-            func (g *Game) {
-                g.points = readPointsFromFile()
-                // g.prerenderedFrames = make([]Frame, 0)
-                g.prerenderIndex = 0
-                if (g.points == nil) {
-                    fmt.Printf("Unable to read points from file.\n")
-                }
-            },
-            false,
-        })
-        g.buttons = append(g.buttons, &Button{1485.0, 950.0, 415.0, 110.0,
-            []string{
-                "======   =======  ||     || =====    || ====== =====   ",
-                "||       |     |  ||     || ||   ||  || ||     ||   || ",
-                "||      ||     || ||     || ||    || || ||     ||    ||",
-                "======  ||     || ||     || ======   || ====== ======  ",
-                "||      ||     || ||     || ||   ||  || ||     ||   || ",
-                "||       |     |  ||     || ||    || || ||     ||    ||",
-                "||       =======   =======  ||     | || ====== ||     |",
-            },
-            func (g *Game) {
-                if (len(g.points)>0) {
-                    g.state = REVEALING
-                    g.revealIndex = 0
-                    g.fourierIndex = 0
-                }
-            },
-            false,
-        })
-        // g.prerenderedFrames = make([]Frame, 0)
-        g.state = START
-    case START:
-        g.buttons[START_BUTTON].CheckIfClicked(g)
+        g.buildWidgets()
+        g.refreshGallery()
+        if (!g.setState(g.options.startState)) {
+            g.setState(DRAWING)
+        }
     case DRAWING:
-        buttonPressed := g.buttons[CLEAR_BUTTON].CheckIfClicked(g)
-        buttonPressed = buttonPressed || g.buttons[SAVE_BUTTON].CheckIfClicked(g)
-        buttonPressed = buttonPressed || g.buttons[LOAD_BUTTON].CheckIfClicked(g)
-        buttonPressed = buttonPressed || g.buttons[FOURIER_BUTTON].CheckIfClicked(g)
-
-        if !buttonPressed && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-            x, y := ebiten.CursorPosition()
+        // One contact draws; a second finger turns the touch into a pinch until all are lifted.
+        if !pointerCaptured && len(g.input.pointer.Contacts) == 1 && !g.camera.pinching {
+            x, y := g.camera.ToWorld(g.input.pointer.Contacts[0].X, g.input.pointer.Contacts[0].Y)
             dim := len(g.points)
-            if (dim==0 || float64(x)!=g.points[dim-1].x || float64(y)!=g.points[dim-1].y) {
-                g.points = append(g.points, Point{float64(x), float64(y)})
+            if (dim==0 || x!=g.points[dim-1].x || y!=g.points[dim-1].y) {
+                g.points = append(g.points, g.capturePoint(x, y))
+                if (g.guess.open) {
+                    g.setGuessOpen(false)
+                }
             }
         }
     case REVEALING:
-        if g.revealIndex<len(g.points) && !ebiten.IsKeyPressed(ebiten.KeyS){
+        if g.revealIndex<len(g.points) && !g.keymap.Triggered(SKIP_REVEAL_ACTION, g.state, &g.input) {
             g.revealIndex++
         } else {
-            g.state = COMPUTING
+            g.setState(COMPUTING)
         }
     case COMPUTING:
-        pointsLen := len(g.points)
-        sequenceX := make([]float64, pointsLen)
-        sequenceY := make([]float64, pointsLen)
-        for i:=0; i<pointsLen; i++ {
-            sequenceX[i] = g.points[i].x
-            sequenceY[i] = g.points[i].y
-        }
-        shiftSequence(sequenceX, float64(-g.windowSize.width)/2)
-        shiftSequence(sequenceY, float64(-g.windowSize.height)/2)
-        g.fourierX = fourier.DiscreteFourierTransform(sequenceX, true)
-        g.fourierY = fourier.DiscreteFourierTransform(sequenceY, true)
-
-        sequenceX = fourier.InverseDFT(g.fourierX)
-        sequenceY = fourier.InverseDFT(g.fourierY)
-
-        g.fourierPoints = make([]Point, len(sequenceX))
-        for i:=0; i<pointsLen; i++ {
-            g.fourierPoints[i].x = sequenceX[i]+float64(g.windowSize.width)/2
-            g.fourierPoints[i].y = sequenceY[i]+float64(g.windowSize.height)/2
-        }
-
-        g.fourierIndex = 0
-
-        g.state = FOURIER
-
-/* PRERENDERING DISABLED
-        if g.prerenderIndex == len(g.fourierX) {
-            g.state = FOURIER
-        } else {
-            expandedFramesSlice := make([]Frame, len(g.fourierX))
-            copy(expandedFramesSlice, g.prerenderedFrames)
-            g.prerenderedFrames = expandedFramesSlice
-            g.state = PRERENDERING
+        g.model = computeModel(g.points, g.windowSize.width, g.windowSize.height, g.options.maxEpicycles, g.options.byTime, &g.options.filter, &g.options.window)
+        g.unfilteredPath = nil
+        if (g.options.filter.active()) {
+            g.unfilteredPath = computeModel(g.points, g.windowSize.width, g.windowSize.height, g.options.maxEpicycles, g.options.byTime, nil, &g.options.window).Path
         }
-        
-    case PRERENDERING:
-        if g.prerenderIndex<len(g.fourierX)  {
-            preRenderBatchOfFrames(g)
-        } else {
-            g.fourierIndex = 0
-            g.state = FOURIER
-        }
-*/
-
+        g.setState(FOURIER)
     case FOURIER:
-        if g.fourierIndex<len(g.fourierX)-1  {
-            g.fourierIndex++
+        g.fourierTime += g.options.speed
+        if int(g.fourierTime)<g.model.Len()-1  {
+            g.fourierIndex = int(g.fourierTime)
+            g.camera.Follow(g.model.Path[g.fourierIndex].X, g.model.Path[g.fourierIndex].Y)
+            if (g.recorder != nil) {
+                g.recordFrame()
+            }
+        } else if (g.playlist != nil) {
+            // The hold runs whether or not the run is recorded, and a recording keeps
+            // the held drawing until the next item stops it.
+            if (g.recorder != nil) {
+                g.recordFrame()
+            }
+            g.updatePlaylistHold()
+        } else if (g.spectrum.open) {
+            // Keep playing while the spectrum is edited; a recording covers one run.
+            if (g.recorder != nil) {
+                g.stopRecording()
+            }
+            g.fourierTime = 0
+            g.fourierIndex = 0
         } else {
-            g.state = DRAWING
+            g.setState(DRAWING)
         }
+    case GALLERY:
+        g.updateGallery()
+    case BROWSING:
+        g.updateFileBrowser(pointerCaptured)
+    case EDITING:
+        g.updateEditor(pointerCaptured)
+    case MORPHING:
+        g.updateMorph()
     }
-
     return nil
 }
 
@@ -496,77 +427,87 @@ func (g *Game) Update() error {
 // Draw draws the game screen.
 // Draw is called every frame (typically 1/60[s] for 60Hz display).
 func (g *Game) Draw(screen *ebiten.Image) {
-    screen.Fill(color.Black)
-
-    color1 := color.RGBA{64, 64, 64, 64}
-    color2 := color.RGBA{192, 192, 192, 255}
-
-    circleWidth := 3.0
+    screen.Fill(g.theme.Background)
 
     switch g.state {
-    case START:
-        drawButton(screen, g.buttons[START_BUTTON])
     case DRAWING:
-        for i:=1; i<len(g.points); i++ {
-            ebitenutil.DrawLine(screen, g.points[i-1].x, g.points[i-1].y, g.points[i].x, g.points[i].y, color1)
-            if (g.toggleDots) {
-                ebitenutil.DrawCircle(screen, g.points[i].x, g.points[i].y, circleWidth, color2)
-            }
-        }
-        drawButton(screen, g.buttons[CLEAR_BUTTON])
-        drawButton(screen, g.buttons[SAVE_BUTTON])
-        drawButton(screen, g.buttons[LOAD_BUTTON])
-        drawButton(screen, g.buttons[FOURIER_BUTTON])
+        g.drawPoints(screen, len(g.points))
     case REVEALING:
-        text.Draw(screen, "Click S to skip", basicfont.Face7x13, 940, 20, color.White)
-        for i:=1; i<g.revealIndex; i++ {
-            ebitenutil.DrawLine(screen, g.points[i-1].x, g.points[i-1].y, g.points[i].x, g.points[i].y, color1)
-            if (g.toggleDots) {
-                ebitenutil.DrawCircle(screen, g.points[i].x, g.points[i].y, circleWidth, color2)
-            }
-        }
-    case PRERENDERING:
-        textOnScreen := fmt.Sprintf("Prerendering: %.2f%%", float64(g.prerenderIndex)/float64(len(g.fourierX))*100)
-        text.Draw(screen, textOnScreen, basicfont.Face7x13, 900, 530, color.White)
-        ebitenutil.DrawRect(screen, 760, 560, float64(g.prerenderIndex)/float64(len(g.fourierX))*400, 40, color.White)
+        g.drawPoints(screen, g.revealIndex)
     case FOURIER:
-        color3 := color.RGBA{255, 255, 255, 255}
-        circleWidthBold := 4.0
-        x1, y1 := drawFourierEpicycles(screen, screen, g.fourierX, g.fourierIndex, float64(g.windowSize.width)/2 , 100, 0.0, g.toggleEpicycles)
-        x2, y2 := drawFourierEpicycles(screen, screen, g.fourierY, g.fourierIndex, 200, float64(g.windowSize.height)/2, -math.Pi/2, g.toggleEpicycles)
-
-        vector.DrawFilledCircle(screen, float32(x1), float32(y1), float32(6.0), color.RGBA{255, 0, 0, 100}, false)
-        vector.DrawFilledCircle(screen, float32(x2), float32(y2), float32(6.0), color.RGBA{0, 255, 0, 100}, false)
-        
-        if (y2 >= 200) {
-            ebitenutil.DrawLine(screen, x1, y1, x1, float64(g.windowSize.height), color.White)
-        } else {
-            ebitenutil.DrawLine(screen, x1, 0, x1, y1, color.White)
+        x1, y1, x2, y2 := g.drawFourierScene(screen, &g.camera)
+        if (g.unfilteredPath != nil) {
+            g.drawFilterOverlay(screen)
         }
-        if (x1 >= 200) {
-            ebitenutil.DrawLine(screen, x2, y2, float64(g.windowSize.width), y2, color.White)
-        } else {
-            ebitenutil.DrawLine(screen, 0, y2, x2, y2, color.White)
+        if (g.spectrum.open) {
+            g.drawSpectrumOverlay(screen)
         }
-
-        for i:=1; i<g.fourierIndex; i++ {
-            ebitenutil.DrawLine(screen, g.fourierPoints[i-1].x, g.fourierPoints[i-1].y, g.fourierPoints[i].x, g.fourierPoints[i].y, color1)
-            if (g.toggleDots) {
-				ebitenutil.DrawCircle(screen, g.fourierPoints[i].x, g.fourierPoints[i].y, circleWidthBold, color3)
-			}
-		}
+        g.drawMagnifier(screen, x1, y1, x2, y2)
+        g.drawRecordingIndicator(screen)
+    case BROWSING:
+        g.drawFileBrowser(screen)
+    case EDITING:
+        g.drawEditor(screen)
+    case MORPHING:
+        g.drawFourierScene(screen, &g.camera)
 	}
-    if (g.state!=PREPARING && g.state!=START) {
-        if (g.toggleDots) {
-            text.Draw(screen, "Points visualization: enabled      - Click V to disable", basicfont.Face7x13, 20, 20, color.White)
-        } else {
-            text.Draw(screen, "Points visualization: disabled     - Click C to enable", basicfont.Face7x13, 20, 20, color.White)
+    g.drawPlaylistFade(screen)
+    if (g.widgets != nil && g.playlist == nil) {
+        g.widgets.Draw(screen, int(g.state), &g.style)
+    }
+    if (g.showHelp && g.state != BROWSING) {
+        g.drawHelpOverlay(screen)
+    }
+}
+
+// drawFourierScene draws the current FOURIER frame onto target through camera: the
+// epicycles, their tips and guides, the trail and the dots. It returns the board
+// positions of the tips of the x and y chains.
+func (g *Game) drawFourierScene(target *ebiten.Image, camera *Camera) (x1, y1, x2, y2 float64) {
+    color3 := g.theme.Dots
+    circleWidthBold := 4.0
+    frame := g.model.Frame(float64(g.fourierIndex))
+    drawFourierEpicycles(target, target, &frame.X, g.toggleEpicycles, g.theme, &g.epicycleStyle, camera)
+    drawFourierEpicycles(target, target, &frame.Y, g.toggleEpicycles, g.theme, &g.epicycleStyle, camera)
+    x1, y1 = frame.X.Tip.X, frame.X.Tip.Y
+    x2, y2 = frame.Y.Tip.X, frame.Y.Tip.Y
+    sx1, sy1 := camera.ToScreen(x1, y1)
+    sx2, sy2 := camera.ToScreen(x2, y2)
+
+    vector.DrawFilledCircle(target, float32(sx1), float32(sy1), float32(6.0), g.theme.TipX, false)
+    vector.DrawFilledCircle(target, float32(sx2), float32(sy2), float32(6.0), g.theme.TipY, false)
+    
+    if (y2 >= 200) {
+        ebitenutil.DrawLine(target, sx1, sy1, sx1, camera.height, g.theme.Guides)
+    } else {
+        ebitenutil.DrawLine(target, sx1, 0, sx1, sy1, g.theme.Guides)
+    }
+    if (x1 >= 200) {
+        ebitenutil.DrawLine(target, sx2, sy2, camera.width, sy2, g.theme.Guides)
+    } else {
+        ebitenutil.DrawLine(target, 0, sy2, sx2, sy2, g.theme.Guides)
+    }
+
+    drawTrail(target, camera.ToScreenPath(g.model.Path), len(frame.Path), g.trailStyle, g.options.trailLength, g.theme)
+    if (g.toggleDots) {
+        for i:=1; i<len(frame.Path); i++ {
+            x, y := camera.ToScreen(frame.Path[i].X, frame.Path[i].Y)
+            ebitenutil.DrawCircle(target, x, y, circleWidthBold, color3)
         }
+    }
 
-        if (g.toggleEpicycles) {
-            text.Draw(screen, "Epicycles visualization: enabled   - Click F to disable", basicfont.Face7x13, 20, 40, color.White)
-        } else {
-            text.Draw(screen, "Epicycles visualization: disabled  - Click D to enable", basicfont.Face7x13, 20, 40, color.White)
+    return x1, y1, x2, y2
+}
+
+// drawPoints draws the first end points of the drawing through the camera.
+func (g *Game) drawPoints(screen *ebiten.Image, end int) {
+    circleWidth := 3.0
+    for i:=1; i<end; i++ {
+        x0, y0 := g.camera.ToScreen(g.points[i-1].x, g.points[i-1].y)
+        x1, y1 := g.camera.ToScreen(g.points[i].x, g.points[i].y)
+        ebitenutil.DrawLine(screen, x0, y0, x1, y1, g.theme.Trail)
+        if (g.toggleDots) {
+            ebitenutil.DrawCircle(screen, x1, y1, circleWidth, g.theme.Dots)
         }
     }
 }
@@ -579,17 +520,119 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 }
 
 func main() {
+    if (len(os.Args) > 1 && os.Args[1] == "compute") {
+        if err := runCompute(os.Args[2:]); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+    if (len(os.Args) > 1 && os.Args[1] == "sheet") {
+        if err := runSheet(os.Args[2:]); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+    if (len(os.Args) > 1 && os.Args[1] == "svg") {
+        if err := runSVG(os.Args[2:]); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+
+    options := parseOptions(os.Args[1:])
+
     game := &Game{}
     game.state = PREPARING
-    game.windowSize = struct{ width, height int }{1920, 1080}
+    game.machine = game.newStateMachine()
+    game.options = options
+    game.windowSize = struct{ width, height int }{options.width, options.height}
+    game.toggleDots = options.dots
+    game.toggleEpicycles = options.epicycles
+    game.trailStyle = options.trailStyle
+    game.epicycleStyle = options.epicycleStyle
+    game.camera = newCamera(options.width, options.height)
+    game.magnifier = newMagnifier(options.magnifier, options.magnifierZoom)
+
+    keymap, err := loadKeymap(options.keymap)
+    if err != nil {
+        log.Fatal(err)
+    }
+    game.keymap = keymap
+
+    themes, err := loadThemes(options.themesDir)
+    if err != nil {
+        log.Fatal(err)
+    }
+    game.themes = themes
+    themeIndex := findTheme(themes, options.theme)
+    if (themeIndex < 0) {
+        log.Fatalf("unknown theme %q", options.theme)
+    }
+    game.setTheme(themeIndex)
+
+    if (options.input != "") {
+        points, err := readPointsFromFile(options.input)
+        if err != nil {
+            log.Fatal(err)
+        }
+        game.points = normalizePoints(points, &options.normalization, options.width, options.height)
+    }
+    if (options.morph != "") {
+        files := strings.Split(options.morph, ",")
+        if (len(files) != 2) {
+            log.Fatalf("-morph expects two point files separated by a comma, got %q", options.morph)
+        }
+        if err := game.startMorph(files[0], files[1]); err != nil {
+            log.Fatal(err)
+        }
+        game.options.startState = MORPHING
+    }
+    if (options.playlist != "") {
+        playlist, err := loadPlaylist(options.playlist, themes, &game.options, themeIndex)
+        if err != nil {
+            log.Fatal(err)
+        }
+        game.playlist = playlist
+        game.playItem(0)
+        game.options.startState = REVEALING
+        ebiten.SetCursorMode(ebiten.CursorModeHidden)
+    }
+
+    game.inputSource = &LiveInput{}
+    if (options.replay != "") {
+        replay, err := NewFileInput(options.replay)
+        if err != nil {
+            log.Fatal(err)
+        }
+        game.inputSource = replay
+    }
+    if (options.recordInput != "") {
+        recorder, err := NewInputRecorder(game.inputSource, options.recordInput)
+        if err != nil {
+            log.Fatal(err)
+        }
+        game.inputSource = recorder
+        game.inputRecorder = recorder
+    }
 
     // Set the Ebiten game parameters.
     ebiten.SetWindowTitle("Fourier Board")
     ebiten.SetWindowResizable(true)
     ebiten.SetWindowSize(game.windowSize.width, game.windowSize.height)
-
-    // Run the game.
-    if err := ebiten.RunGame(game); err != nil {
+    ebiten.SetFullscreen(options.fullscreen)
+
+    // Run the game. log.Fatal skips deferred calls, so the input recording is closed
+    // before it.
+    err = ebiten.RunGame(game)
+    if (game.inputRecorder != nil) {
+        if closeErr := game.inputRecorder.Close(); closeErr != nil {
+            log.Printf("Unable to save the input recording: %v", closeErr)
+        }
+    }
+    if err != nil {
         log.Fatal(err)
     }
-}
\ No newline at end of file
+}
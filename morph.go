@@ -0,0 +1,184 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "math/cmplx"
+    "path/filepath"
+    "sort"
+
+    "github.com/hajimehoshi/ebiten/v2"
+
+    "fourier-drawing/engine"
+    "fourier-drawing/fourier"
+    "fourier-drawing/ui"
+)
+
+type MorphMode int
+const (
+    // LINEAR_MORPH blends the terms as complex numbers.
+    LINEAR_MORPH MorphMode = iota
+    // POLAR_MORPH blends magnitudes and phases separately, phases the short way round,
+    // so terms turn into each other instead of shrinking through zero.
+    POLAR_MORPH
+    MORPH_MODES
+)
+
+var morphModeNames = [MORPH_MODES]string{"linear", "polar"}
+
+func morphModeByName(name string) (MorphMode, bool) {
+    for i, n := range morphModeNames {
+        if n == name {
+            return MorphMode(i), true
+        }
+    }
+    return 0, false
+}
+
+// Morph animates between the spectra of two drawings resampled to the same number of
+// points, so their terms pair up by frequency. The blend goes from the first drawing
+// to the second and back every 2*seconds while the chains keep turning.
+type Morph struct {
+    fromName, toName    string
+    from, to            [2][]fourier.FourierElement
+    mode                MorphMode
+    seconds             float64
+    ticks               int
+    // blend is the current position between the drawings, 0 for from and 1 for to.
+    blend               float64
+}
+
+// resampleByLength returns n points evenly spaced along the polyline through points.
+func resampleByLength(points []Point, n int) []Point {
+    lengths := make([]float64, len(points))
+    for i:=1; i<len(points); i++ {
+        lengths[i] = lengths[i-1]+math.Hypot(points[i].x-points[i-1].x, points[i].y-points[i-1].y)
+    }
+    total := lengths[len(lengths)-1]
+    out := make([]Point, n)
+    j := 1
+    for i := range out {
+        at := total*float64(i)/float64(n)
+        for j < len(points)-1 && lengths[j] < at {
+            j++
+        }
+        a, b := points[j-1], points[j]
+        u := 0.0
+        if segment := lengths[j]-lengths[j-1]; segment > 0 {
+            u = (at-lengths[j-1])/segment
+        }
+        out[i] = Point{x: a.x+u*(b.x-a.x), y: a.y+u*(b.y-a.y), pressure: 1}
+    }
+    return out
+}
+
+// boardSpectra returns the unsorted spectra of points taken around the board centre.
+func boardSpectra(points []Point, width, height int) [2][]fourier.FourierElement {
+    sequenceX := make([]float64, len(points))
+    sequenceY := make([]float64, len(points))
+    for i, p := range points {
+        sequenceX[i] = p.x-float64(width)/2
+        sequenceY[i] = p.y-float64(height)/2
+    }
+    return [2][]fourier.FourierElement{
+        fourier.DiscreteFourierTransform(sequenceX, false),
+        fourier.DiscreteFourierTransform(sequenceY, false),
+    }
+}
+
+func newMorph(fromName string, from []Point, toName string, to []Point, n, width, height int, mode MorphMode, seconds float64) (*Morph, error) {
+    if (len(from) < 2 || len(to) < 2) {
+        return nil, errTooFewPoints
+    }
+    return &Morph{
+        fromName:   fromName,
+        toName:     toName,
+        from:       boardSpectra(resampleByLength(from, n), width, height),
+        to:         boardSpectra(resampleByLength(to, n), width, height),
+        mode:       mode,
+        seconds:    seconds,
+    }, nil
+}
+
+// blendTerm returns the term between a and b at u.
+func blendTerm(a, b complex128, u float64, mode MorphMode) complex128 {
+    if (mode == LINEAR_MORPH) {
+        return a+complex(u, 0)*(b-a)
+    }
+    magnitude := cmplx.Abs(a)+u*(cmplx.Abs(b)-cmplx.Abs(a))
+    phaseA := cmplx.Phase(a)
+    if (a == 0) {
+        phaseA = cmplx.Phase(b)
+    }
+    phaseB := cmplx.Phase(b)
+    if (b == 0) {
+        phaseB = phaseA
+    }
+    return cmplx.Rect(magnitude, phaseA+u*math.Remainder(phaseB-phaseA, 2*math.Pi))
+}
+
+// advance moves the blend on by one tick, easing in and out at both drawings.
+func (m *Morph) advance() {
+    m.ticks++
+    turn := float64(m.ticks)/(m.seconds*float64(ebiten.TPS()))
+    m.blend = 0.5-0.5*math.Cos(math.Pi*turn)
+}
+
+// model returns the chains of the current blend, largest terms first.
+func (m *Morph) model(width, height int) *engine.Model {
+    var terms [2][]fourier.FourierElement
+    for axis := range terms {
+        terms[axis] = make([]fourier.FourierElement, len(m.from[axis]))
+        for k := range terms[axis] {
+            terms[axis][k] = fourier.FourierElement{
+                Freq:   m.from[axis][k].Freq,
+                Val:    blendTerm(m.from[axis][k].Val, m.to[axis][k].Val, m.blend, m.mode),
+            }
+        }
+        sort.SliceStable(terms[axis], func(i, j int) bool {
+            return cmplx.Abs(terms[axis][i].Val) > cmplx.Abs(terms[axis][j].Val)
+        })
+    }
+    return newBoardModel(terms[0], terms[1], width, height)
+}
+
+func (g *Game) setMorphMode(mode MorphMode) {
+    g.options.morphMode = (mode+MORPH_MODES)%MORPH_MODES
+    if (g.morph != nil) {
+        g.morph.mode = g.options.morphMode
+    }
+    g.widgets.Get("morph-mode").(*ui.Button).Label = "Blend: " + morphModeNames[g.options.morphMode]
+}
+
+// startMorph morphs between the point files fromPath and toPath from the start screen.
+func (g *Game) startMorph(fromPath, toPath string) error {
+    from, err := readPointsFromFile(fromPath)
+    if err != nil {
+        return err
+    }
+    to, err := readPointsFromFile(toPath)
+    if err != nil {
+        return err
+    }
+    morph, err := newMorph(filepath.Base(fromPath), from, filepath.Base(toPath), to, g.options.morphPoints, g.windowSize.width, g.windowSize.height, g.options.morphMode, g.options.morphSeconds)
+    if err != nil {
+        return fmt.Errorf("%s to %s: %w", fromPath, toPath, err)
+    }
+    g.morph = morph
+    return nil
+}
+
+func (g *Game) updateMorph() {
+    g.morph.advance()
+    g.model = g.morph.model(g.windowSize.width, g.windowSize.height)
+    g.fourierTime += g.options.speed
+    if (int(g.fourierTime) >= g.model.Len()) {
+        g.fourierTime = 0
+    }
+    g.fourierIndex = int(g.fourierTime)
+    g.camera.Follow(g.model.Path[g.fourierIndex].X, g.model.Path[g.fourierIndex].Y)
+}
+
+func (g *Game) morphLabel() string {
+    return fmt.Sprintf("%s  %3.0f%%  %s", g.morph.fromName, g.morph.blend*100, g.morph.toName)
+}
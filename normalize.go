@@ -0,0 +1,217 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "math"
+    "strings"
+
+    "fourier-drawing/ui"
+)
+
+type StartPoint int
+const (
+    KEEP_START StartPoint = iota
+    // TOP_START and LEFT_START start at the highest and the leftmost point.
+    TOP_START
+    LEFT_START
+    // FARTHEST_START starts at the point farthest from the centroid.
+    FARTHEST_START
+    START_POINTS
+)
+
+var startPointNames = [START_POINTS]string{"keep", "top", "left", "farthest"}
+
+// Normalization is how drawings are brought onto the canvas when they are loaded and
+// before they are transformed. Every step can be applied again without changing the
+// result.
+type Normalization struct {
+    // center moves the centroid of the drawing to the centre of the canvas.
+    center      bool
+    // fit scales the drawing to fill the canvas, leaving padding on every side.
+    fit         bool
+    padding     float64
+    // align turns the drawing about its centroid so its principal axis is horizontal.
+    align       bool
+    start       StartPoint
+}
+
+func (n *Normalization) active() bool {
+    return n.center || n.fit || n.align || n.start != KEEP_START
+}
+
+// addNormalizeFlags registers the normalization flags on flags. The returned function
+// checks and stores them once the flags are parsed.
+func addNormalizeFlags(flags *flag.FlagSet, n *Normalization) func() error {
+    var start string
+    flags.BoolVar(&n.center, "center", false, "move the centroid of the drawing to the centre of the canvas")
+    flags.BoolVar(&n.fit, "fit", false, "scale the drawing to fit the canvas, -fit-padding from its edges")
+    flags.Float64Var(&n.padding, "fit-padding", 60, "space left around the drawing by -fit, in pixels")
+    flags.BoolVar(&n.align, "align", false, "turn the drawing so its principal axis is horizontal")
+    flags.StringVar(&start, "start-point", "keep", "point the drawing starts at: keep, top, left or farthest from the centroid")
+
+    return func() error {
+        found := false
+        for i, name := range startPointNames {
+            if (name == strings.ToLower(start)) {
+                n.start, found = StartPoint(i), true
+            }
+        }
+        if (!found) {
+            return fmt.Errorf("invalid -start-point value %q", start)
+        }
+        if (n.padding < 0) {
+            return fmt.Errorf("-fit-padding cannot be negative")
+        }
+        return nil
+    }
+}
+
+// centroid is the centre of mass of the drawing as a closed line, so where the points
+// are dense does not pull it. A drawing without length falls back to the mean point.
+func centroid(points []Point) (float64, float64) {
+    var sx, sy, sumX, sumY, total float64
+    for i, a := range points {
+        b := points[(i+1)%len(points)]
+        length := math.Hypot(b.x-a.x, b.y-a.y)
+        sx += (a.x+b.x)/2*length
+        sy += (a.y+b.y)/2*length
+        sumX += a.x
+        sumY += a.y
+        total += length
+    }
+    if (total == 0) {
+        return sumX/float64(len(points)), sumY/float64(len(points))
+    }
+    return sx/total, sy/total
+}
+
+// principalAngle is the angle of the direction along which the points spread most.
+func principalAngle(points []Point, cx, cy float64) float64 {
+    var xx, yy, xy float64
+    for _, p := range points {
+        xx += (p.x-cx)*(p.x-cx)
+        yy += (p.y-cy)*(p.y-cy)
+        xy += (p.x-cx)*(p.y-cy)
+    }
+    return math.Atan2(2*xy, xx-yy)/2
+}
+
+// rotateStart makes point s the first one. Timed drawings keep their rhythm: the points
+// moved to the end follow on from the old last point.
+func rotateStart(points []Point, s int) []Point {
+    n := len(points)
+    out := append(append([]Point(nil), points[s:]...), points[:s]...)
+    if (s == 0 || !hasTiming(points)) {
+        return out
+    }
+    step := (points[n-1].t-points[0].t)/float64(n-1)
+    for i := range out {
+        if (i < n-s) {
+            out[i].t -= points[s].t-points[0].t
+        } else {
+            out[i].t += points[n-1].t+step-points[s].t
+        }
+    }
+    return out
+}
+
+func startIndex(points []Point, start StartPoint) int {
+    cx, cy := centroid(points)
+    best, bestValue := 0, math.Inf(-1)
+    for i, p := range points {
+        var value float64
+        switch start {
+        case TOP_START:
+            value = -p.y
+        case LEFT_START:
+            value = -p.x
+        case FARTHEST_START:
+            value = math.Hypot(p.x-cx, p.y-cy)
+        default:
+            return 0
+        }
+        if (value > bestValue) {
+            best, bestValue = i, value
+        }
+    }
+    return best
+}
+
+// normalizePoints returns a normalized copy of points for a width by height canvas:
+// aligned, then centred, then fitted, then started at the chosen point.
+func normalizePoints(points []Point, n *Normalization, width, height int) []Point {
+    if (len(points) == 0 || !n.active()) {
+        return points
+    }
+    out := append([]Point(nil), points...)
+    transform := func(f func(x, y float64) (float64, float64)) {
+        for i := range out {
+            out[i].x, out[i].y = f(out[i].x, out[i].y)
+        }
+    }
+    w, h := float64(width), float64(height)
+
+    if (n.align) {
+        cx, cy := centroid(out)
+        sin, cos := math.Sincos(-principalAngle(out, cx, cy))
+        transform(func(x, y float64) (float64, float64) {
+            return cx+(x-cx)*cos-(y-cy)*sin, cy+(x-cx)*sin+(y-cy)*cos
+        })
+    }
+    if (n.center) {
+        cx, cy := centroid(out)
+        transform(func(x, y float64) (float64, float64) {
+            return x-cx+w/2, y-cy+h/2
+        })
+    }
+    if (n.fit) {
+        minX, minY := math.Inf(1), math.Inf(1)
+        maxX, maxY := math.Inf(-1), math.Inf(-1)
+        for _, p := range out {
+            minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+            minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+        }
+        // Scaled about the canvas centre when centred, so the centroid stays there;
+        // otherwise the bounding box is centred first.
+        cx, cy := (minX+maxX)/2, (minY+maxY)/2
+        halfW, halfH := (maxX-minX)/2, (maxY-minY)/2
+        if (n.center) {
+            cx, cy = w/2, h/2
+            halfW, halfH = math.Max(maxX-cx, cx-minX), math.Max(maxY-cy, cy-minY)
+        }
+        scale := math.Min((w/2-n.padding)/math.Max(halfW, 1e-9), (h/2-n.padding)/math.Max(halfH, 1e-9))
+        if (scale > 0 && !math.IsInf(scale, 0)) {
+            transform(func(x, y float64) (float64, float64) {
+                return w/2+(x-cx)*scale, h/2+(y-cy)*scale
+            })
+        }
+    }
+    return rotateStart(out, startIndex(out, n.start))
+}
+
+// normalizeDrawing normalizes the points on the board with the current settings.
+func (g *Game) normalizeDrawing() {
+    if (!g.options.normalization.active() || len(g.points) == 0) {
+        return
+    }
+    g.points = normalizePoints(g.points, &g.options.normalization, g.windowSize.width, g.windowSize.height)
+    g.pointsChanged()
+}
+
+// startAtSelection makes the only selected point the first point of the drawing.
+func (g *Game) startAtSelection() {
+    selected := g.editor.singleSelection()
+    if (selected <= 0) {
+        return
+    }
+    g.points = rotateStart(g.points, selected)
+    g.editor.reset(len(g.points))
+    g.editor.selected[0] = true
+    g.pointsChanged()
+}
+
+func (g *Game) setStartPoint(start StartPoint) {
+    g.options.normalization.start = (start+START_POINTS)%START_POINTS
+    g.widgets.Get("start-point").(*ui.Button).Label = "Start: " + startPointNames[g.options.normalization.start]
+}
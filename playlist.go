@@ -0,0 +1,151 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/hajimehoshi/ebiten/v2"
+)
+
+// PlaylistItem is one drawing of a playlist. Settings left out or 0 take the value
+// given on the command line.
+type PlaylistItem struct {
+    File        string      `json:"file"`
+    Epicycles   int         `json:"epicycles,omitempty"`
+    Speed       float64     `json:"speed,omitempty"`
+    Theme       string      `json:"theme,omitempty"`
+    // Hold is how long, in seconds, the finished drawing stays before the next one.
+    Hold        float64     `json:"hold,omitempty"`
+    points      []Point
+    themeIndex  int
+}
+
+// Playlist runs its drawings one after the other, REVEALING then FOURIER, and
+// starts over after the last one, crossfading between them without any input.
+type Playlist struct {
+    Items       []*PlaylistItem `json:"items"`
+    // Hold is the hold time of the items that set none.
+    Hold        float64         `json:"hold,omitempty"`
+    Crossfade   float64         `json:"crossfade,omitempty"`
+
+    index       int
+    holdTicks   int
+    // fade is the last frame of the previous item, faded out over the next one.
+    fade        *ebiten.Image
+    fadeTicks   int
+    // The command line settings the items fall back to.
+    epicycles   int
+    speed       float64
+    themeIndex  int
+}
+
+// loadPlaylist reads a JSON playlist, e.g.
+// {"crossfade": 1.5, "items": [{"file": "deer.txt", "epicycles": 80, "speed": 2, "theme": "light", "hold": 4}]},
+// with files relative to the playlist, and loads every drawing up front.
+func loadPlaylist(filePath string, themes []*Theme, options *Options, themeIndex int) (*Playlist, error) {
+    data, err := os.ReadFile(filePath)
+    if err != nil {
+        return nil, err
+    }
+    p := &Playlist{Hold: 3, Crossfade: 1}
+    if err := json.Unmarshal(data, p); err != nil {
+        return nil, fmt.Errorf("%s: %w", filePath, err)
+    }
+    if (len(p.Items) == 0) {
+        return nil, fmt.Errorf("%s: the playlist has no items", filePath)
+    }
+    if (p.Hold < 0 || p.Crossfade < 0) {
+        return nil, fmt.Errorf("%s: hold and crossfade cannot be negative", filePath)
+    }
+    p.epicycles, p.speed, p.themeIndex = options.maxEpicycles, options.speed, themeIndex
+
+    for i, item := range p.Items {
+        if (item.Epicycles < 0 || item.Speed < 0 || item.Hold < 0) {
+            return nil, fmt.Errorf("%s: item %d: epicycles, speed and hold cannot be negative", filePath, i+1)
+        }
+        file := item.File
+        if (!filepath.IsAbs(file)) {
+            file = filepath.Join(filepath.Dir(filePath), file)
+        }
+        item.points, err = readPointsFromFile(file)
+        if err != nil {
+            return nil, fmt.Errorf("%s: item %d: %w", filePath, i+1, err)
+        }
+        if (len(item.points) < 2) {
+            return nil, fmt.Errorf("%s: item %d: %w", filePath, i+1, errTooFewPoints)
+        }
+        item.themeIndex = -1
+        if (item.Theme != "") {
+            item.themeIndex = findTheme(themes, item.Theme)
+            if (item.themeIndex < 0) {
+                return nil, fmt.Errorf("%s: item %d: unknown theme %q", filePath, i+1, item.Theme)
+            }
+        }
+    }
+    return p, nil
+}
+
+// playItem sets up the drawing and the settings of item i; REVEALING starts it.
+func (g *Game) playItem(i int) {
+    p := g.playlist
+    p.index = i
+    p.holdTicks = 0
+    item := p.Items[i]
+
+    g.points = append([]Point(nil), item.points...)
+    g.options.maxEpicycles = p.epicycles
+    if (item.Epicycles > 0) {
+        g.options.maxEpicycles = item.Epicycles
+    }
+    g.options.speed = p.speed
+    if (item.Speed > 0) {
+        g.options.speed = item.Speed
+    }
+    themeIndex := p.themeIndex
+    if (item.themeIndex >= 0) {
+        themeIndex = item.themeIndex
+    }
+    if (themeIndex != g.themeIndex) {
+        g.setTheme(themeIndex)
+    }
+    g.camera.Reset()
+}
+
+// updatePlaylistHold is called for every tick FOURIER stays on the finished drawing.
+// Once the hold time is over it keeps the frame for the crossfade and starts the
+// next item.
+func (g *Game) updatePlaylistHold() {
+    p := g.playlist
+    hold := p.Hold
+    if (p.Items[p.index].Hold > 0) {
+        hold = p.Items[p.index].Hold
+    }
+    p.holdTicks++
+    if (float64(p.holdTicks) < hold*float64(ebiten.TPS())) {
+        return
+    }
+
+    if (p.Crossfade > 0) {
+        if (p.fade == nil) {
+            p.fade = ebiten.NewImage(g.windowSize.width, g.windowSize.height)
+        }
+        p.fade.Fill(g.theme.Background)
+        g.drawFourierScene(p.fade, &g.camera)
+        p.fadeTicks = int(p.Crossfade*float64(ebiten.TPS()))
+    }
+    g.playItem((p.index+1)%len(p.Items))
+    g.setState(REVEALING)
+}
+
+// drawPlaylistFade draws the previous item over the current one, fading out.
+func (g *Game) drawPlaylistFade(screen *ebiten.Image) {
+    p := g.playlist
+    if (p == nil || p.fadeTicks <= 0) {
+        return
+    }
+    opts := &ebiten.DrawImageOptions{}
+    opts.ColorScale.ScaleAlpha(float32(float64(p.fadeTicks)/(p.Crossfade*float64(ebiten.TPS()))))
+    screen.DrawImage(p.fade, opts)
+}
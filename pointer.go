@@ -0,0 +1,75 @@
+package main
+
+import (
+    "slices"
+
+    "github.com/hajimehoshi/ebiten/v2"
+)
+
+// MOUSE_POINTER is the ID of the mouse among the contacts; touches keep their own IDs.
+const MOUSE_POINTER = -1
+
+type Pointer struct {
+    ID      int         `json:"id"`
+    X       float64     `json:"x"`
+    Y       float64     `json:"y"`
+}
+
+// PointerFrame is the pointer state of one tick, whatever device it comes from.
+type PointerFrame struct {
+    // X, Y is where hovering and single pointer actions happen: the mouse cursor,
+    // or the first touch.
+    X           float64     `json:"x"`
+    Y           float64     `json:"y"`
+    // Contacts are the pointers pressing the board: the left mouse button or the
+    // fingers on a touchscreen, in ID order.
+    Contacts    []Pointer   `json:"contacts,omitempty"`
+    // Panning is set while the right or middle mouse button is held.
+    Panning     bool        `json:"panning,omitempty"`
+    Wheel       float64     `json:"wheel,omitempty"`
+}
+
+func (f PointerFrame) Pressed() bool {
+    return len(f.Contacts) > 0
+}
+
+// EbitenPointers reads the mouse and the touchscreen for LiveInput. While any finger
+// is down the touches win over the mouse, which some platforms move along with them.
+type EbitenPointers struct {
+    touchIDs    []ebiten.TouchID
+    touched     bool
+    lastX       float64
+    lastY       float64
+}
+
+func (p *EbitenPointers) NextFrame() PointerFrame {
+    var frame PointerFrame
+    p.touchIDs = ebiten.AppendTouchIDs(p.touchIDs[:0])
+    slices.Sort(p.touchIDs)
+
+    if len(p.touchIDs) > 0 {
+        for _, id := range p.touchIDs {
+            x, y := ebiten.TouchPosition(id)
+            frame.Contacts = append(frame.Contacts, Pointer{int(id), float64(x), float64(y)})
+        }
+        frame.X, frame.Y = frame.Contacts[0].X, frame.Contacts[0].Y
+        p.touched = true
+        p.lastX, p.lastY = frame.X, frame.Y
+        return frame
+    }
+    if p.touched {
+        // Report the release where the last finger left rather than at the cursor.
+        p.touched = false
+        frame.X, frame.Y = p.lastX, p.lastY
+        return frame
+    }
+
+    x, y := ebiten.CursorPosition()
+    frame.X, frame.Y = float64(x), float64(y)
+    if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+        frame.Contacts = []Pointer{{MOUSE_POINTER, frame.X, frame.Y}}
+    }
+    frame.Panning = ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) || ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle)
+    _, frame.Wheel = ebiten.Wheel()
+    return frame
+}
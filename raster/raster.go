@@ -0,0 +1,140 @@
+// Package raster draws lines, circles and text into plain images without a GPU,
+// for the commands that render without opening a window.
+package raster
+
+import (
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "math"
+    "os"
+
+    "golang.org/x/image/font"
+    "golang.org/x/image/font/gofont/goregular"
+    "golang.org/x/image/font/opentype"
+    "golang.org/x/image/math/fixed"
+    "golang.org/x/image/vector"
+)
+
+type Point struct {
+    X, Y float64
+}
+
+// Canvas is an RGBA image with an antialiasing rasterizer. Every shape is filled
+// on its own, so shapes drawn later cover the earlier ones.
+type Canvas struct {
+    Image   *image.RGBA
+    r       vector.Rasterizer
+}
+
+func NewCanvas(width, height int, background color.Color) *Canvas {
+    c := &Canvas{Image: image.NewRGBA(image.Rect(0, 0, width, height))}
+    draw.Draw(c.Image, c.Image.Rect, image.NewUniform(background), image.Point{}, draw.Src)
+    return c
+}
+
+func (c *Canvas) begin() {
+    c.r.Reset(c.Image.Rect.Dx(), c.Image.Rect.Dy())
+}
+
+func (c *Canvas) fill(clr color.Color) {
+    c.r.Draw(c.Image, c.Image.Rect, image.NewUniform(clr), image.Point{})
+}
+
+// quad adds the rectangle of half width w around the segment a-b. All segments are
+// wound the same way, so overlaps at the joins add up instead of cancelling.
+func (c *Canvas) quad(a, b Point, w float64) {
+    dx, dy := b.X-a.X, b.Y-a.Y
+    length := math.Hypot(dx, dy)
+    if length == 0 {
+        return
+    }
+    nx, ny := -dy/length*w, dx/length*w
+    c.r.MoveTo(float32(a.X+nx), float32(a.Y+ny))
+    c.r.LineTo(float32(b.X+nx), float32(b.Y+ny))
+    c.r.LineTo(float32(b.X-nx), float32(b.Y-ny))
+    c.r.LineTo(float32(a.X-nx), float32(a.Y-ny))
+    c.r.ClosePath()
+}
+
+// circle adds a circle wound like the quads.
+func (c *Canvas) circle(center Point, radius float64) {
+    steps := max(12, int(radius*2))
+    c.r.MoveTo(float32(center.X+radius), float32(center.Y))
+    for i := 1; i < steps; i++ {
+        a := -2*math.Pi*float64(i)/float64(steps)
+        c.r.LineTo(float32(center.X+radius*math.Cos(a)), float32(center.Y+radius*math.Sin(a)))
+    }
+    c.r.ClosePath()
+}
+
+// Polyline strokes the path through points with the given width.
+func (c *Canvas) Polyline(points []Point, width float64, clr color.Color) {
+    c.begin()
+    for i := 1; i < len(points); i++ {
+        c.quad(points[i-1], points[i], width/2)
+    }
+    // Round joins hide the notches between the segments.
+    if width > 2 {
+        for _, p := range points {
+            c.circle(p, width/2)
+        }
+    }
+    c.fill(clr)
+}
+
+func (c *Canvas) Line(a, b Point, width float64, clr color.Color) {
+    c.Polyline([]Point{a, b}, width, clr)
+}
+
+func (c *Canvas) FillCircle(center Point, radius float64, clr color.Color) {
+    c.begin()
+    c.circle(center, radius)
+    c.fill(clr)
+}
+
+// StrokeCircle draws the outline of a circle as a closed polyline.
+func (c *Canvas) StrokeCircle(center Point, radius, width float64, clr color.Color) {
+    steps := max(16, min(720, int(radius)))
+    points := make([]Point, steps+1)
+    for i := range points {
+        a := 2*math.Pi*float64(i)/float64(steps)
+        points[i] = Point{center.X+radius*math.Cos(a), center.Y+radius*math.Sin(a)}
+    }
+    c.Polyline(points, width, clr)
+}
+
+func (c *Canvas) FillRect(r image.Rectangle, clr color.Color) {
+    draw.Draw(c.Image, r, image.NewUniform(clr), image.Point{}, draw.Over)
+}
+
+var fontData, _ = opentype.Parse(goregular.TTF)
+
+// Face returns the Go Regular font at the given pixel size.
+func Face(size float64) font.Face {
+    face, err := opentype.NewFace(fontData, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+    if err != nil {
+        panic(err)
+    }
+    return face
+}
+
+// Text draws str with its top-left corner at (x, y).
+func (c *Canvas) Text(str string, face font.Face, x, y float64, clr color.Color) {
+    d := font.Drawer{Dst: c.Image, Src: image.NewUniform(clr), Face: face}
+    d.Dot = fixed.Point26_6{X: fixed.Int26_6(x*64), Y: fixed.Int26_6(y*64)+face.Metrics().Ascent}
+    d.DrawString(str)
+}
+
+func (c *Canvas) SavePNG(path string) error {
+    file, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    if err := png.Encode(file, c.Image); err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
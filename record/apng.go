@@ -0,0 +1,201 @@
+package record
+
+import (
+    "bufio"
+    "bytes"
+    "compress/zlib"
+    "encoding/binary"
+    "errors"
+    "hash/crc32"
+    "image"
+    "math"
+    "os"
+)
+
+// APNG streams frames to an animated PNG file. Every frame is stored whole as 8 bit
+// RGBA; the frame count in the acTL chunk is filled in on Close.
+type APNG struct {
+    file        *os.File
+    w           *bufio.Writer
+    delayNum    uint16
+    delayDen    uint16
+    size        image.Point
+    frames      uint32
+    sequence    uint32
+    data        bytes.Buffer
+}
+
+const (
+    // acTLOffset is where the acTL data starts: after the signature, the IHDR chunk
+    // and the length and type of acTL.
+    acTLOffset = 8+(12+13)+8
+)
+
+func NewAPNG(path string, fps float64) (*APNG, error) {
+    file, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    num, den := delayFraction(fps)
+    return &APNG{file: file, w: bufio.NewWriter(file), delayNum: num, delayDen: den}, nil
+}
+
+// delayFraction returns the frame delay, 1/fps seconds, as the closest fraction whose
+// terms fit the 16 bits of fcTL: 30 fps is 1/30 and 29.97 fps 100/2997.
+func delayFraction(fps float64) (uint16, uint16) {
+    // Convergents of the continued fraction of 1/fps, each closer than the last.
+    num, den := uint64(1), uint64(max(1, math.Round(fps)))
+    h1, h2, k1, k2 := uint64(1), uint64(0), uint64(0), uint64(1)
+    x := 1/fps
+    for i := 0; i < 32; i++ {
+        a := math.Floor(x)
+        if (a > math.MaxUint16) {
+            break
+        }
+        h, k := uint64(a)*h1+h2, uint64(a)*k1+k2
+        if (h > math.MaxUint16 || k > math.MaxUint16) {
+            break
+        }
+        if (h > 0) {
+            num, den = h, k
+        }
+        h1, h2, k1, k2 = h, h1, k, k1
+        if (x-a < 1e-9) {
+            break
+        }
+        x = 1/(x-a)
+    }
+    return uint16(num), uint16(min(den, math.MaxUint16))
+}
+
+func (a *APNG) chunk(kind string, data []byte) error {
+    var header [8]byte
+    binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+    copy(header[4:], kind)
+    crc := crc32.NewIEEE()
+    crc.Write(header[4:])
+    crc.Write(data)
+    var footer [4]byte
+    binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+    for _, b := range [][]byte{header[:], data, footer[:]} {
+        if _, err := a.w.Write(b); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (a *APNG) writeHeader() error {
+    if _, err := a.w.WriteString("\x89PNG\r\n\x1a\n"); err != nil {
+        return err
+    }
+    ihdr := make([]byte, 13)
+    binary.BigEndian.PutUint32(ihdr[0:], uint32(a.size.X))
+    binary.BigEndian.PutUint32(ihdr[4:], uint32(a.size.Y))
+    ihdr[8] = 8 // bit depth
+    ihdr[9] = 6 // RGBA
+    if err := a.chunk("IHDR", ihdr); err != nil {
+        return err
+    }
+    // Frame count and loop count (0 loops forever); the count is patched on Close.
+    return a.chunk("acTL", make([]byte, 8))
+}
+
+// compress zlib-compresses the rows of frame, each with filter type None.
+func (a *APNG) compress(frame *image.RGBA) ([]byte, error) {
+    a.data.Reset()
+    z, err := zlib.NewWriterLevel(&a.data, zlib.BestSpeed)
+    if err != nil {
+        return nil, err
+    }
+    row := make([]byte, 1+4*a.size.X)
+    for y := 0; y < a.size.Y; y++ {
+        pix := frame.Pix[y*frame.Stride:y*frame.Stride+4*a.size.X]
+        // RGBA is premultiplied, PNG expects straight alpha.
+        for i := 0; i < len(pix); i += 4 {
+            r, g, b, alpha := pix[i], pix[i+1], pix[i+2], pix[i+3]
+            if alpha != 0 && alpha != 255 {
+                r = uint8(int(r)*255/int(alpha))
+                g = uint8(int(g)*255/int(alpha))
+                b = uint8(int(b)*255/int(alpha))
+            }
+            row[1+i], row[2+i], row[3+i], row[4+i] = r, g, b, alpha
+        }
+        if _, err := z.Write(row); err != nil {
+            return nil, err
+        }
+    }
+    if err := z.Close(); err != nil {
+        return nil, err
+    }
+    return a.data.Bytes(), nil
+}
+
+func (a *APNG) WriteFrame(frame image.Image) error {
+    if err := checkSize(frame, &a.size); err != nil {
+        return err
+    }
+    if a.frames == 0 {
+        if err := a.writeHeader(); err != nil {
+            return err
+        }
+    }
+
+    fctl := make([]byte, 26)
+    binary.BigEndian.PutUint32(fctl[0:], a.sequence)
+    binary.BigEndian.PutUint32(fctl[4:], uint32(a.size.X))
+    binary.BigEndian.PutUint32(fctl[8:], uint32(a.size.Y))
+    binary.BigEndian.PutUint16(fctl[20:], a.delayNum)
+    binary.BigEndian.PutUint16(fctl[22:], a.delayDen)
+    a.sequence++
+    if err := a.chunk("fcTL", fctl); err != nil {
+        return err
+    }
+
+    data, err := a.compress(toRGBA(frame))
+    if err != nil {
+        return err
+    }
+    // The first frame is the default image, the others go into fdAT chunks that
+    // start with a sequence number.
+    if a.frames == 0 {
+        err = a.chunk("IDAT", data)
+    } else {
+        fdat := make([]byte, 4+len(data))
+        binary.BigEndian.PutUint32(fdat, a.sequence)
+        copy(fdat[4:], data)
+        a.sequence++
+        err = a.chunk("fdAT", fdat)
+    }
+    a.frames++
+    return err
+}
+
+func (a *APNG) Close() error {
+    if err := a.finish(); err != nil {
+        a.file.Close()
+        return err
+    }
+    return a.file.Close()
+}
+
+func (a *APNG) finish() error {
+    if a.frames == 0 {
+        return errors.New("apng: no frames")
+    }
+    if err := a.chunk("IEND", nil); err != nil {
+        return err
+    }
+    if err := a.w.Flush(); err != nil {
+        return err
+    }
+
+    actl := make([]byte, 8)
+    binary.BigEndian.PutUint32(actl, a.frames)
+    crc := crc32.NewIEEE()
+    crc.Write([]byte("acTL"))
+    crc.Write(actl)
+    actl = binary.BigEndian.AppendUint32(actl, crc.Sum32())
+    _, err := a.file.WriteAt(actl, acTLOffset)
+    return err
+}
@@ -0,0 +1,146 @@
+package record
+
+import (
+    "bytes"
+    "encoding/binary"
+    "hash/crc32"
+    "image"
+    "image/color"
+    "image/png"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+type pngChunk struct {
+    kind    string
+    data    []byte
+}
+
+// readChunks splits a PNG file into its chunks, checking the signature and every CRC.
+func readChunks(t *testing.T, file []byte) []pngChunk {
+    t.Helper()
+    if (!bytes.HasPrefix(file, []byte("\x89PNG\r\n\x1a\n"))) {
+        t.Fatal("missing PNG signature")
+    }
+    var chunks []pngChunk
+    for rest := file[8:]; len(rest) > 0; {
+        if (len(rest) < 12) {
+            t.Fatalf("truncated chunk after %d chunks", len(chunks))
+        }
+        length := int(binary.BigEndian.Uint32(rest))
+        if (len(rest) < 12+length) {
+            t.Fatalf("chunk %q runs past the end of the file", rest[4:8])
+        }
+        kindAndData := rest[4:8+length]
+        if want, got := crc32.ChecksumIEEE(kindAndData), binary.BigEndian.Uint32(rest[8+length:]); got != want {
+            t.Errorf("chunk %q has CRC %08x, want %08x", kindAndData[:4], got, want)
+        }
+        chunks = append(chunks, pngChunk{string(kindAndData[:4]), kindAndData[4:]})
+        rest = rest[12+length:]
+    }
+    return chunks
+}
+
+func testFrame(c color.RGBA) *image.RGBA {
+    frame := image.NewRGBA(image.Rect(0, 0, 3, 2))
+    for y := 0; y < 2; y++ {
+        for x := 0; x < 3; x++ {
+            frame.SetRGBA(x, y, c)
+        }
+    }
+    // Premultiplied half-transparent red, stored as straight alpha.
+    frame.SetRGBA(2, 1, color.RGBA{64, 0, 0, 128})
+    return frame
+}
+
+func TestAPNG(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "out.png")
+    a, err := NewAPNG(path, 29.97)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, c := range []color.RGBA{{10, 20, 30, 255}, {200, 100, 50, 255}} {
+        if err := a.WriteFrame(testFrame(c)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := a.Close(); err != nil {
+        t.Fatal(err)
+    }
+    file, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Decoders that know nothing of APNG show the first frame.
+    img, err := png.Decode(bytes.NewReader(file))
+    if err != nil {
+        t.Fatalf("image/png cannot decode the default image: %v", err)
+    }
+    if (img.Bounds() != image.Rect(0, 0, 3, 2)) {
+        t.Fatalf("default image bounds = %v, want 3x2", img.Bounds())
+    }
+    for _, test := range []struct{ x, y int; want color.NRGBA }{{0, 0, color.NRGBA{10, 20, 30, 255}}, {2, 1, color.NRGBA{127, 0, 0, 128}}} {
+        if got := color.NRGBAModel.Convert(img.At(test.x, test.y)); got != test.want {
+            t.Errorf("default image at (%d, %d) = %v, want %v", test.x, test.y, got, test.want)
+        }
+    }
+
+    chunks := readChunks(t, file)
+    var kinds []string
+    for _, c := range chunks {
+        kinds = append(kinds, c.kind)
+    }
+    if want := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "IEND"}; !reflect.DeepEqual(kinds, want) {
+        t.Fatalf("chunks = %v, want %v", kinds, want)
+    }
+    if frames, plays := binary.BigEndian.Uint32(chunks[1].data), binary.BigEndian.Uint32(chunks[1].data[4:]); frames != 2 || plays != 0 {
+        t.Errorf("acTL = %d frames, %d plays, want 2 frames looping forever", frames, plays)
+    }
+    // fcTL and fdAT share one sequence, from 0 with no gaps.
+    for i, at := range []int{2, 4, 5} {
+        if got := binary.BigEndian.Uint32(chunks[at].data); got != uint32(i) {
+            t.Errorf("%s sequence number = %d, want %d", chunks[at].kind, got, i)
+        }
+    }
+    for _, at := range []int{2, 4} {
+        fctl := chunks[at].data
+        width, height := binary.BigEndian.Uint32(fctl[4:]), binary.BigEndian.Uint32(fctl[8:])
+        num, den := binary.BigEndian.Uint16(fctl[20:]), binary.BigEndian.Uint16(fctl[22:])
+        if (width != 3 || height != 2 || num != 100 || den != 2997) {
+            t.Errorf("fcTL = %dx%d, delay %d/%d, want 3x2, delay 100/2997", width, height, num, den)
+        }
+    }
+}
+
+func TestAPNGWithoutFrames(t *testing.T) {
+    a, err := NewAPNG(filepath.Join(t.TempDir(), "empty.png"), 30)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := a.Close(); err == nil {
+        t.Error("closing an APNG without frames succeeded")
+    }
+}
+
+func TestDelayFraction(t *testing.T) {
+    tests := []struct {
+        fps         float64
+        num, den    uint16
+    }{
+        {30, 1, 30},
+        {60, 1, 60},
+        {29.97, 100, 2997},
+        {23.976, 125, 2997},
+        {0.5, 2, 1},
+        {1000.5, 2, 2001},
+        {100000, 1, 65535},
+    }
+    for _, test := range tests {
+        if num, den := delayFraction(test.fps); num != test.num || den != test.den {
+            t.Errorf("delayFraction(%v) = %d/%d, want %d/%d", test.fps, num, den, test.num, test.den)
+        }
+    }
+}
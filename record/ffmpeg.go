@@ -0,0 +1,74 @@
+package record
+
+import (
+    "errors"
+    "fmt"
+    "image"
+    "io"
+    "os"
+    "os/exec"
+)
+
+// FFmpeg pipes raw RGBA frames to a local ffmpeg process, which picks the codec
+// from the extension of the output file.
+type FFmpeg struct {
+    path    string
+    fps     float64
+    size    image.Point
+    cmd     *exec.Cmd
+    stdin   io.WriteCloser
+}
+
+// FFmpegAvailable reports whether an ffmpeg executable is on the PATH.
+func FFmpegAvailable() bool {
+    _, err := exec.LookPath("ffmpeg")
+    return err == nil
+}
+
+func NewFFmpeg(path string, fps float64) (*FFmpeg, error) {
+    if !FFmpegAvailable() {
+        return nil, errors.New("ffmpeg not found in PATH")
+    }
+    return &FFmpeg{path: path, fps: fps}, nil
+}
+
+// start runs ffmpeg once the frame size is known.
+func (f *FFmpeg) start() error {
+    f.cmd = exec.Command("ffmpeg", "-y", "-loglevel", "error",
+        "-f", "rawvideo", "-pix_fmt", "rgba",
+        "-s", fmt.Sprintf("%dx%d", f.size.X, f.size.Y),
+        "-r", fmt.Sprint(f.fps),
+        "-i", "-",
+        // Most encoders want yuv420p, which needs even dimensions.
+        "-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2",
+        "-pix_fmt", "yuv420p",
+        f.path)
+    f.cmd.Stderr = os.Stderr
+    stdin, err := f.cmd.StdinPipe()
+    if err != nil {
+        return err
+    }
+    f.stdin = stdin
+    return f.cmd.Start()
+}
+
+func (f *FFmpeg) WriteFrame(frame image.Image) error {
+    if err := checkSize(frame, &f.size); err != nil {
+        return err
+    }
+    if f.cmd == nil {
+        if err := f.start(); err != nil {
+            return err
+        }
+    }
+    _, err := f.stdin.Write(toRGBA(frame).Pix)
+    return err
+}
+
+func (f *FFmpeg) Close() error {
+    if f.cmd == nil {
+        return errors.New("ffmpeg: no frames")
+    }
+    f.stdin.Close()
+    return f.cmd.Wait()
+}
@@ -0,0 +1,144 @@
+package record
+
+import (
+    "image"
+    "image/color"
+    "image/gif"
+    "math"
+    "os"
+    "sort"
+)
+
+// GIF collects the frames of an animated GIF, each quantized to its own 256 color
+// palette, and encodes them on Close. The paletted frames are kept in memory, one
+// byte per pixel.
+type GIF struct {
+    path    string
+    delay   int
+    size    image.Point
+    anim    gif.GIF
+}
+
+// NewGIF creates a GIF sink. GIF delays are in hundredths of a second, so fps is
+// rounded to the closest rate that divides 100.
+func NewGIF(path string, fps float64) *GIF {
+    return &GIF{path: path, delay: max(1, int(math.Round(100/fps)))}
+}
+
+func (g *GIF) WriteFrame(frame image.Image) error {
+    if err := checkSize(frame, &g.size); err != nil {
+        return err
+    }
+    g.anim.Image = append(g.anim.Image, quantize(toRGBA(frame)))
+    g.anim.Delay = append(g.anim.Delay, g.delay)
+    return nil
+}
+
+func (g *GIF) Close() error {
+    file, err := os.Create(g.path)
+    if err != nil {
+        return err
+    }
+    if err := gif.EncodeAll(file, &g.anim); err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
+
+// colorBox is a box of the RGB cube holding some of the sampled colors, for the
+// median cut.
+type colorBox struct {
+    colors []color.RGBA
+}
+
+// longestAxis returns the channel (0 red, 1 green, 2 blue) with the widest range.
+func (b colorBox) longestAxis() (axis int, length int) {
+    lo := [3]uint8{255, 255, 255}
+    hi := [3]uint8{}
+    for _, c := range b.colors {
+        for i, v := range [3]uint8{c.R, c.G, c.B} {
+            lo[i] = min(lo[i], v)
+            hi[i] = max(hi[i], v)
+        }
+    }
+    for i := range 3 {
+        if int(hi[i])-int(lo[i]) > length {
+            axis, length = i, int(hi[i])-int(lo[i])
+        }
+    }
+    return axis, length
+}
+
+func (b colorBox) average() color.RGBA {
+    var r, g, bl int
+    for _, c := range b.colors {
+        r += int(c.R)
+        g += int(c.G)
+        bl += int(c.B)
+    }
+    n := len(b.colors)
+    return color.RGBA{uint8(r/n), uint8(g/n), uint8(bl/n), 255}
+}
+
+func channel(c color.RGBA, axis int) uint8 {
+    switch axis {
+    case 0:
+        return c.R
+    case 1:
+        return c.G
+    }
+    return c.B
+}
+
+// quantize maps img to a palette built by median cut from a sample of its pixels.
+// Alpha is dropped: the frames are opaque.
+func quantize(img *image.RGBA) *image.Paletted {
+    b := img.Rect
+    step := max(1, b.Dx()*b.Dy()/65536)
+    var sample []color.RGBA
+    for i := 0; i < len(img.Pix); i += 4*step {
+        sample = append(sample, color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], 255})
+    }
+
+    boxes := []colorBox{{sample}}
+    for len(boxes) < 256 {
+        // Split the box with the widest range at the median of that range.
+        best, bestAxis, bestLength := -1, 0, 0
+        for i, box := range boxes {
+            if len(box.colors) < 2 {
+                continue
+            }
+            if axis, length := box.longestAxis(); length > bestLength {
+                best, bestAxis, bestLength = i, axis, length
+            }
+        }
+        if best < 0 {
+            break
+        }
+        colors := boxes[best].colors
+        sort.Slice(colors, func(i, j int) bool { return channel(colors[i], bestAxis) < channel(colors[j], bestAxis) })
+        boxes[best] = colorBox{colors[:len(colors)/2]}
+        boxes = append(boxes, colorBox{colors[len(colors)/2:]})
+    }
+
+    palette := make(color.Palette, len(boxes))
+    for i, box := range boxes {
+        palette[i] = box.average()
+    }
+
+    // Nearest palette entries are looked up once per 15 bit color.
+    var lookup [1 << 15]int16
+    for i := range lookup {
+        lookup[i] = -1
+    }
+    out := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), palette)
+    for i, j := 0, 0; i < len(img.Pix); i, j = i+4, j+1 {
+        key := int(img.Pix[i]>>3)<<10 | int(img.Pix[i+1]>>3)<<5 | int(img.Pix[i+2]>>3)
+        if lookup[key] < 0 {
+            lookup[key] = int16(palette.Index(color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], 255}))
+        }
+        out.Pix[j] = uint8(lookup[key])
+    }
+    return out
+}
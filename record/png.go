@@ -0,0 +1,37 @@
+package record
+
+import (
+    "fmt"
+    "image"
+    "image/png"
+    "os"
+    "path/filepath"
+)
+
+// PNGSequence writes every frame to its own numbered PNG file in a directory.
+type PNGSequence struct {
+    dir     string
+    frames  int
+    encoder png.Encoder
+}
+
+func NewPNGSequence(dir string) (*PNGSequence, error) {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, err
+    }
+    return &PNGSequence{dir: dir, encoder: png.Encoder{CompressionLevel: png.BestSpeed}}, nil
+}
+
+func (s *PNGSequence) WriteFrame(frame image.Image) error {
+    file, err := os.Create(filepath.Join(s.dir, fmt.Sprintf("frame_%05d.png", s.frames)))
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+    s.frames++
+    return s.encoder.Encode(file, frame)
+}
+
+func (s *PNGSequence) Close() error {
+    return nil
+}
@@ -0,0 +1,72 @@
+// Package record writes animations frame by frame to image sequences, animated
+// images and video files.
+package record
+
+import (
+    "fmt"
+    "image"
+    "image/draw"
+    "sort"
+)
+
+// FrameSink receives the frames of an animation in order. All frames of a sink
+// must have the same size, and Close must be called to finish the output.
+type FrameSink interface {
+    WriteFrame(frame image.Image) error
+    Close() error
+}
+
+// Format describes an output format: how to open a sink for it and the extension
+// of its default file name ("" for a directory).
+type Format struct {
+    Extension   string
+    open        func(path string, fps float64) (FrameSink, error)
+}
+
+var Formats = map[string]Format{
+    "png":      {"", func(path string, fps float64) (FrameSink, error) { return NewPNGSequence(path) }},
+    "gif":      {".gif", func(path string, fps float64) (FrameSink, error) { return NewGIF(path, fps), nil }},
+    "apng":     {".png", func(path string, fps float64) (FrameSink, error) { return NewAPNG(path, fps) }},
+    "ffmpeg":   {".mp4", func(path string, fps float64) (FrameSink, error) { return NewFFmpeg(path, fps) }},
+}
+
+// FormatNames returns the names of the formats in alphabetical order.
+func FormatNames() []string {
+    names := make([]string, 0, len(Formats))
+    for name := range Formats {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// NewSink opens a sink of the named format writing to path at fps frames per second.
+func NewSink(format, path string, fps float64) (FrameSink, error) {
+    f, ok := Formats[format]
+    if !ok {
+        return nil, fmt.Errorf("unknown format %q", format)
+    }
+    return f.open(path, fps)
+}
+
+// toRGBA returns frame as an *image.RGBA with its origin at (0, 0), copying it only
+// when needed.
+func toRGBA(frame image.Image) *image.RGBA {
+    if rgba, ok := frame.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) && rgba.Stride == 4*rgba.Rect.Dx() {
+        return rgba
+    }
+    b := frame.Bounds()
+    rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+    draw.Draw(rgba, rgba.Rect, frame, b.Min, draw.Src)
+    return rgba
+}
+
+func checkSize(frame image.Image, size *image.Point) error {
+    s := frame.Bounds().Size()
+    if *size == (image.Point{}) {
+        *size = s
+    } else if s != *size {
+        return fmt.Errorf("frame is %dx%d, expected %dx%d", s.X, s.Y, size.X, size.Y)
+    }
+    return nil
+}
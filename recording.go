@@ -0,0 +1,99 @@
+package main
+
+import (
+    "fmt"
+    "image"
+    "time"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/record"
+    "fourier-drawing/ui"
+)
+
+// Recorder captures a FOURIER run into a frame sink. Frames are rendered offscreen
+// from Update, so the output advances with the animation ticks and its frame rate
+// does not depend on how often the display draws.
+type Recorder struct {
+    sink    record.FrameSink
+    path    string
+    fps     float64
+    image   *ebiten.Image
+    pixels  []byte
+    ticks   int
+    frames  int
+}
+
+func defaultRecordPath(format string) string {
+    return "fourier-" + time.Now().Format("20060102-150405") + record.Formats[format].Extension
+}
+
+// startRecording restarts the current run and records it to the end.
+func (g *Game) startRecording() {
+    path := g.options.recordOutput
+    if (path == "") {
+        path = defaultRecordPath(g.options.recordFormat)
+    }
+    sink, err := record.NewSink(g.options.recordFormat, path, g.options.recordFPS)
+    if err != nil {
+        fmt.Printf("Unable to start recording: %v\n", err)
+        return
+    }
+    g.recorder = &Recorder{
+        sink:   sink,
+        path:   path,
+        fps:    g.options.recordFPS,
+        image:  ebiten.NewImage(g.windowSize.width, g.windowSize.height),
+        pixels: make([]byte, 4*g.windowSize.width*g.windowSize.height),
+    }
+    g.fourierTime = 0
+    g.fourierIndex = 0
+    g.recordFrame()
+}
+
+func (g *Game) stopRecording() {
+    r := g.recorder
+    g.recorder = nil
+    r.image.Deallocate()
+    if err := r.sink.Close(); err != nil {
+        fmt.Printf("Unable to finish recording %s: %v\n", r.path, err)
+        return
+    }
+    fmt.Printf("Recorded %d frames to %s\n", r.frames, r.path)
+}
+
+// recordFrame is called once per tick of the run and writes a frame whenever the
+// recording clock, at fps frames per second of ticks, has moved on. Above the tick
+// rate, the frame is written as many times as the clock moved.
+func (g *Game) recordFrame() {
+    r := g.recorder
+    due := int(float64(r.ticks)*r.fps/float64(ebiten.TPS()))+1
+    r.ticks++
+    if (r.frames >= due) {
+        return
+    }
+
+    r.image.Fill(g.theme.Background)
+    g.drawFourierScene(r.image, &g.camera)
+    r.image.ReadPixels(r.pixels)
+    frame := &image.RGBA{Pix: r.pixels, Stride: 4*g.windowSize.width, Rect: image.Rect(0, 0, g.windowSize.width, g.windowSize.height)}
+    for r.frames < due {
+        if err := r.sink.WriteFrame(frame); err != nil {
+            fmt.Printf("Unable to record frame: %v\n", err)
+            g.stopRecording()
+            return
+        }
+        r.frames++
+    }
+}
+
+func (g *Game) drawRecordingIndicator(screen *ebiten.Image) {
+    if (g.recorder == nil) {
+        return
+    }
+    x := float32(g.windowSize.width)/2-80
+    vector.DrawFilledCircle(screen, x, 32, 9, g.theme.Error, true)
+    seconds := float64(g.recorder.frames)/g.recorder.fps
+    ui.DrawText(screen, fmt.Sprintf("REC %02d:%04.1f", int(seconds)/60, seconds-float64(int(seconds)/60*60)), ui.DEFAULT_TEXT_SIZE, float64(x)+18, 21, g.theme.Error)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "image"
+    "math"
+    "os"
+
+    "fourier-drawing/engine"
+    "fourier-drawing/fourier"
+    "fourier-drawing/raster"
+)
+
+// sheetLevels returns 1, 2, 4, ... up to n, with n itself as the last level.
+func sheetLevels(n, maxCells int) []int {
+    var levels []int
+    for k := 1; k < n; k *= 2 {
+        levels = append(levels, k)
+    }
+    levels = append(levels, n)
+    if maxCells > 0 && len(levels) > maxCells {
+        levels = append(levels[:maxCells-1], n)
+    }
+    return levels
+}
+
+// fitPoints scales and centres points into the rectangle r, keeping their aspect ratio.
+func fitPoints(points []Point, r image.Rectangle) []raster.Point {
+    minX, minY := math.Inf(1), math.Inf(1)
+    maxX, maxY := math.Inf(-1), math.Inf(-1)
+    for _, p := range points {
+        minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+        minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+    }
+    scale := math.Min(float64(r.Dx())/math.Max(maxX-minX, 1e-9), float64(r.Dy())/math.Max(maxY-minY, 1e-9))
+    offsetX := float64(r.Min.X)+(float64(r.Dx())-(maxX-minX)*scale)/2
+    offsetY := float64(r.Min.Y)+(float64(r.Dy())-(maxY-minY)*scale)/2
+
+    out := make([]raster.Point, len(points))
+    for i, p := range points {
+        out[i] = raster.Point{X: offsetX+(p.x-minX)*scale, Y: offsetY+(p.y-minY)*scale}
+    }
+    return out
+}
+
+// reconstructLevels returns, for each level, the drawing rebuilt from that many of its
+// largest terms per axis. The sequences are taken around their means, so the constant
+// term is not one of the largest and even one term draws a curve.
+func reconstructLevels(points []Point, levels []int) [][]Point {
+    sequenceX := make([]float64, len(points))
+    sequenceY := make([]float64, len(points))
+    for i, p := range points {
+        sequenceX[i], sequenceY[i] = p.x, p.y
+    }
+    center := engine.Vec{X: mean(sequenceX), Y: mean(sequenceY)}
+    shiftSequence(sequenceX, -center.X)
+    shiftSequence(sequenceY, -center.Y)
+    spectrumX := fourier.DiscreteFourierTransform(sequenceX, true)
+    spectrumY := fourier.DiscreteFourierTransform(sequenceY, true)
+
+    out := make([][]Point, len(levels))
+    for i, k := range levels {
+        model := engine.NewModel(
+            engine.Axis{Terms: fourier.KeepLargest(spectrumX, k)},
+            engine.Axis{Terms: fourier.KeepLargest(spectrumY, k), Phase: -math.Pi/2},
+            center,
+        )
+        out[i] = make([]Point, model.Len())
+        for j, p := range model.Path {
+            out[i][j] = Point{x: p.X, y: p.Y}
+        }
+    }
+    return out
+}
+
+// renderSheet draws one cell per level: the reconstruction from the level's largest
+// terms of the sorted spectrum over the original drawing, labelled with the level.
+func renderSheet(points []Point, levels []int, columns, cellWidth, cellHeight int, theme *Theme) *raster.Canvas {
+    reconstructions := reconstructLevels(points, levels)
+
+    rows := (len(levels)+columns-1)/columns
+    canvas := raster.NewCanvas(columns*cellWidth, rows*cellHeight, theme.Background)
+    face := raster.Face(20)
+    padding := 16
+    labelHeight := 32
+    original := scaleAlpha(theme.Dots, 0.35)
+    trail := theme.Trail
+    trail.A = 255
+
+    for i, k := range levels {
+        cell := image.Rect(0, 0, cellWidth, cellHeight).Add(image.Pt(i%columns*cellWidth, i/columns*cellHeight))
+        canvas.FillRect(image.Rect(cell.Max.X-1, cell.Min.Y, cell.Max.X, cell.Max.Y), theme.Guides)
+        canvas.FillRect(image.Rect(cell.Min.X, cell.Max.Y-1, cell.Max.X, cell.Max.Y), theme.Guides)
+
+        reconstructed := reconstructions[i]
+
+        // Both curves share the scale of the original, so the cells compare directly.
+        area := image.Rect(cell.Min.X+padding, cell.Min.Y+labelHeight, cell.Max.X-padding, cell.Max.Y-padding)
+        all := append(append([]Point{}, points...), reconstructed...)
+        fitted := fitPoints(all, area)
+        canvas.Polyline(fitted[:len(points)], 1, original)
+        canvas.Polyline(append(fitted[len(points):], fitted[len(points)]), 2, trail)
+
+        label := fmt.Sprintf("K = %d", k)
+        if k == len(points) {
+            label += " (all)"
+        }
+        canvas.Text(label, face, float64(cell.Min.X+padding), float64(cell.Min.Y+8), theme.Text)
+    }
+    return canvas
+}
+
+// runSheet implements the "sheet" subcommand: a PNG grid of the reconstructions of a
+// drawing with 1, 2, 4, 8, ... epicycles per axis.
+func runSheet(args []string) error {
+    flags := flag.NewFlagSet("sheet", flag.ExitOnError)
+    flags.Usage = func() {
+        fmt.Fprintf(flags.Output(), "Usage:\n  fourier-drawing sheet [flags] <points file>\n\nFlags:\n")
+        flags.PrintDefaults()
+    }
+    output := flags.String("o", "sheet.png", "output PNG file")
+    columns := flags.Int("columns", 4, "cells per row")
+    cellWidth := flags.Int("cell-width", 480, "cell width in pixels")
+    cellHeight := flags.Int("cell-height", 360, "cell height in pixels")
+    maxCells := flags.Int("max-cells", 0, "maximum number of cells, the last one always uses every term (0 for no limit)")
+    themeName := flags.String("theme", "light", "color theme")
+    themesDir := flags.String("themes-dir", userThemesDir(), "directory with user JSON themes")
+    var normalization Normalization
+    checkNormalize := addNormalizeFlags(flags, &normalization)
+    flags.Parse(args)
+
+    if err := checkNormalize(); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        flags.Usage()
+        os.Exit(2)
+    }
+    if (flags.NArg() != 1 || *columns <= 0 || *cellWidth <= 0 || *cellHeight <= 0 || *maxCells == 1) {
+        flags.Usage()
+        os.Exit(2)
+    }
+
+    themes, err := loadThemes(*themesDir)
+    if err != nil {
+        return err
+    }
+    themeIndex := findTheme(themes, *themeName)
+    if (themeIndex < 0) {
+        return fmt.Errorf("unknown theme %q", *themeName)
+    }
+
+    points, err := readPointsFromFile(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+    if (len(points) < 2) {
+        return fmt.Errorf("%s contains fewer than two points", flags.Arg(0))
+    }
+    // Every cell is fitted anyway, so only the alignment and the start point show.
+    points = normalizePoints(points, &normalization, *cellWidth, *cellHeight)
+
+    levels := sheetLevels(len(points), *maxCells)
+    canvas := renderSheet(points, levels, min(*columns, len(levels)), *cellWidth, *cellHeight, themes[themeIndex])
+    return canvas.SavePNG(*output)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+    "math"
+    "testing"
+)
+
+// TestEveryLevelDraws checks that every cell of a sheet draws a curve, the one-term
+// cell included, and that the last one gives the drawing back.
+func TestEveryLevelDraws(t *testing.T) {
+    points := []Point{{x: 800, y: 400}, {x: 1000, y: 400}, {x: 1000, y: 600}, {x: 800, y: 600}, {x: 850, y: 500}}
+    levels := []int{1, 2, 4, len(points)}
+    for i, path := range reconstructLevels(points, levels) {
+        if (len(path) == 0) {
+            t.Fatalf("K = %d: empty path", levels[i])
+        }
+        minX, maxX := math.Inf(1), math.Inf(-1)
+        minY, maxY := math.Inf(1), math.Inf(-1)
+        for _, p := range path {
+            minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+            minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+        }
+        if (maxX-minX < 1 && maxY-minY < 1) {
+            t.Errorf("K = %d: the path collapses to a point at (%.1f, %.1f)", levels[i], minX, minY)
+        }
+    }
+
+    all := reconstructLevels(points, []int{len(points)})[0]
+    for i, p := range points {
+        if (math.Hypot(all[i].x-p.x, all[i].y-p.y) > 1e-6) {
+            t.Errorf("every term: point %d at (%.3f, %.3f), want (%.0f, %.0f)", i, all[i].x, all[i].y, p.x, p.y)
+        }
+    }
+}
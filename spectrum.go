@@ -0,0 +1,290 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "math/cmplx"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/engine"
+    "fourier-drawing/fourier"
+    "fourier-drawing/ui"
+)
+
+const (
+    SPECTRUM_ROW_HEIGHT = 24.0
+    SPECTRUM_ROWS = 18
+    // SPECTRUM_GAIN_RANGE is the largest magnitude a bar can be dragged to, relative
+    // to the largest term of the axis.
+    SPECTRUM_GAIN_RANGE = 1.5
+    // SPECTRUM_PHASE_SPEED is the phase change in radians per pixel of drag.
+    SPECTRUM_PHASE_SPEED = math.Pi/90
+)
+
+// TermEdit is how the user changed one term: its magnitude is multiplied by gain and
+// its phase turned by phase.
+type TermEdit struct {
+    gain    float64
+    phase   float64
+    muted   bool
+    solo    bool
+}
+
+// SpectrumEditor lets the terms of g.model be changed while FOURIER runs. The
+// original values are kept, so every edit can be undone at once.
+type SpectrumEditor struct {
+    open        bool
+    original    [2][]fourier.FourierElement
+    edits       [2][]TermEdit
+    // axis is the chain shown in the list, 0 for x and 1 for y.
+    axis        int
+    selected    int
+    scroll      int
+}
+
+func (s *SpectrumEditor) reset(model *engine.Model) {
+    for axis, terms := range [2][]fourier.FourierElement{model.X.Terms, model.Y.Terms} {
+        s.original[axis] = append([]fourier.FourierElement(nil), terms...)
+        s.edits[axis] = make([]TermEdit, len(terms))
+        for i := range s.edits[axis] {
+            s.edits[axis][i].gain = 1
+        }
+    }
+    s.selected = -1
+    s.scroll = 0
+}
+
+// value returns the edited value of term i of axis.
+func (s *SpectrumEditor) value(axis, i int) complex128 {
+    edit := s.edits[axis][i]
+    if (edit.muted || (!edit.solo && s.anySolo(axis))) {
+        return 0
+    }
+    return s.original[axis][i].Val*cmplx.Rect(edit.gain, edit.phase)
+}
+
+func (s *SpectrumEditor) anySolo(axis int) bool {
+    for _, edit := range s.edits[axis] {
+        if edit.solo {
+            return true
+        }
+    }
+    return false
+}
+
+// maxMagnitude is the magnitude of the largest original term of axis.
+func (s *SpectrumEditor) maxMagnitude(axis int) float64 {
+    m := 0.0
+    for _, term := range s.original[axis] {
+        m = math.Max(m, cmplx.Abs(term.Val))
+    }
+    return m
+}
+
+func (g *Game) modelAxis(axis int) *engine.Axis {
+    if (axis == 0) {
+        return &g.model.X
+    }
+    return &g.model.Y
+}
+
+// applySpectrum copies the terms of axis that changed into the model, which updates
+// the reconstructed path.
+func (g *Game) applySpectrum(axis int) {
+    modelAxis := g.modelAxis(axis)
+    for i := range g.spectrum.edits[axis] {
+        if v := g.spectrum.value(axis, i); v != modelAxis.Terms[i].Val {
+            g.model.SetTerm(modelAxis, i, v)
+        }
+    }
+}
+
+func (g *Game) resetSpectrum() {
+    s := &g.spectrum
+    for axis := range s.edits {
+        for i := range s.edits[axis] {
+            s.edits[axis][i] = TermEdit{gain: 1}
+        }
+        g.applySpectrum(axis)
+    }
+}
+
+func (g *Game) setSpectrumOpen(open bool) {
+    g.spectrum.open = open
+    g.widgets.Get("spectrum").(*ui.Panel).Hidden = !open
+}
+
+// The columns of a SpectrumList row, as offsets from its left edge.
+const (
+    SPECTRUM_BAR_X = 80.0
+    SPECTRUM_BAR_W = 220.0
+    SPECTRUM_PHASE_X = 320.0
+    SPECTRUM_PHASE_W = 110.0
+    SPECTRUM_MUTE_X = 450.0
+    SPECTRUM_SOLO_X = 490.0
+    SPECTRUM_BOX = 30.0
+)
+
+// SpectrumList is the term list of the spectrum editor, largest terms first. A row
+// is selected by a click; dragging its bar sets the magnitude, dragging its phase
+// turns it, and clicking its mute and solo boxes toggles them. The header switches
+// between the chains.
+type SpectrumList struct {
+    ui.Base
+    g           *Game
+    hovered     bool
+    // dragging is the column being dragged in the selected row: "bar", "phase" or "".
+    dragging    string
+    lastX       float64
+}
+
+func (l *SpectrumList) Focusable() bool {
+    return false
+}
+
+func (l *SpectrumList) rowAt(y float64) int {
+    row := int((y-l.Bounds.Y)/SPECTRUM_ROW_HEIGHT)-1
+    if (row < 0 || row >= SPECTRUM_ROWS) {
+        return -1
+    }
+    return l.g.spectrum.scroll+row
+}
+
+func (l *SpectrumList) Update(in *ui.Input) bool {
+    s := &l.g.spectrum
+    l.hovered = l.Bounds.Contains(in.X, in.Y)
+    if (!in.Pressed) {
+        l.dragging = ""
+    }
+    if (l.hovered) {
+        if wheel := l.g.input.pointer.Wheel; wheel != 0 {
+            s.scroll = max(0, min(len(s.edits[s.axis])-SPECTRUM_ROWS, s.scroll-int(math.Round(wheel))))
+        }
+    }
+
+    if (in.JustPressed && l.hovered) {
+        x := in.X-l.Bounds.X
+        if (in.Y-l.Bounds.Y < SPECTRUM_ROW_HEIGHT) {
+            if axis := int(x/SPECTRUM_BAR_X); axis <= 1 && axis != s.axis {
+                s.axis = axis
+                s.selected = -1
+                s.scroll = 0
+            }
+            return true
+        }
+        i := l.rowAt(in.Y)
+        if (i < 0 || i >= len(s.edits[s.axis])) {
+            return true
+        }
+        s.selected = i
+        edit := &s.edits[s.axis][i]
+        switch {
+        case x >= SPECTRUM_BAR_X && x < SPECTRUM_BAR_X+SPECTRUM_BAR_W:
+            l.dragging = "bar"
+        case x >= SPECTRUM_PHASE_X && x < SPECTRUM_PHASE_X+SPECTRUM_PHASE_W:
+            l.dragging = "phase"
+            l.lastX = in.X
+        case x >= SPECTRUM_MUTE_X && x < SPECTRUM_MUTE_X+SPECTRUM_BOX:
+            edit.muted = !edit.muted
+            l.g.applySpectrum(s.axis)
+        case x >= SPECTRUM_SOLO_X && x < SPECTRUM_SOLO_X+SPECTRUM_BOX:
+            edit.solo = !edit.solo
+            l.g.applySpectrum(s.axis)
+        }
+    }
+
+    if (l.dragging != "" && s.selected >= 0) {
+        edit := &s.edits[s.axis][s.selected]
+        original := cmplx.Abs(s.original[s.axis][s.selected].Val)
+        switch l.dragging {
+        case "bar":
+            if (original > 0) {
+                u := math.Max(0, math.Min(1, (in.X-l.Bounds.X-SPECTRUM_BAR_X)/SPECTRUM_BAR_W))
+                edit.gain = u*SPECTRUM_GAIN_RANGE*s.maxMagnitude(s.axis)/original
+            }
+        case "phase":
+            edit.phase = math.Remainder(edit.phase+(in.X-l.lastX)*SPECTRUM_PHASE_SPEED, 2*math.Pi)
+            l.lastX = in.X
+        }
+        l.g.applySpectrum(s.axis)
+    }
+    return l.hovered || l.dragging != ""
+}
+
+func (l *SpectrumList) Draw(screen *ebiten.Image, style *ui.Style) {
+    s := &l.g.spectrum
+    r := l.Bounds
+    size := style.TextSize*0.85
+
+    for axis, name := range []string{"x chain", "y chain"} {
+        clr := style.Disabled
+        if (axis == s.axis) {
+            clr = style.Accent
+        }
+        ui.DrawText(screen, name, size, r.X+float64(axis)*SPECTRUM_BAR_X, r.Y+2, clr)
+    }
+    ui.DrawText(screen, "magnitude", size, r.X+SPECTRUM_BAR_X+90, r.Y+2, style.Text)
+    ui.DrawText(screen, "phase", size, r.X+SPECTRUM_PHASE_X, r.Y+2, style.Text)
+    ui.DrawText(screen, "mute solo", size, r.X+SPECTRUM_MUTE_X-4, r.Y+2, style.Text)
+
+    terms := s.original[s.axis]
+    maxMagnitude := s.maxMagnitude(s.axis)
+    for row := 0; row < SPECTRUM_ROWS && s.scroll+row < len(terms); row++ {
+        i := s.scroll+row
+        edit := s.edits[s.axis][i]
+        y := r.Y+float64(row+1)*SPECTRUM_ROW_HEIGHT
+        if (i == s.selected) {
+            vector.DrawFilledRect(screen, float32(r.X), float32(y), float32(r.W), SPECTRUM_ROW_HEIGHT, style.Hover, false)
+        }
+        clr := style.Text
+        if (s.value(s.axis, i) == 0) {
+            clr = style.Disabled
+        }
+        ui.DrawText(screen, fmt.Sprintf("%+d", fourier.SignedFrequency(terms[i].Freq, len(terms))), size, r.X+4, y+3, clr)
+
+        magnitude := cmplx.Abs(terms[i].Val)*edit.gain
+        if (maxMagnitude > 0) {
+            w := math.Min(1, magnitude/(SPECTRUM_GAIN_RANGE*maxMagnitude))*SPECTRUM_BAR_W
+            vector.DrawFilledRect(screen, float32(r.X+SPECTRUM_BAR_X), float32(y+6), float32(w), SPECTRUM_ROW_HEIGHT-12, clr, false)
+        }
+        vector.StrokeRect(screen, float32(r.X+SPECTRUM_BAR_X), float32(y+6), SPECTRUM_BAR_W, SPECTRUM_ROW_HEIGHT-12, 1, style.Border, false)
+
+        phase := cmplx.Phase(terms[i].Val)+edit.phase
+        cx, cy := r.X+SPECTRUM_PHASE_X+9, y+SPECTRUM_ROW_HEIGHT/2
+        vector.StrokeCircle(screen, float32(cx), float32(cy), 8, 1, style.Border, true)
+        vector.StrokeLine(screen, float32(cx), float32(cy), float32(cx+8*math.Cos(phase)), float32(cy-8*math.Sin(phase)), 1.5, clr, true)
+        ui.DrawText(screen, fmt.Sprintf("%+.0f°", math.Remainder(phase, 2*math.Pi)*180/math.Pi), size, cx+16, y+3, clr)
+
+        for _, box := range []struct{ x float64; on bool }{{SPECTRUM_MUTE_X, edit.muted}, {SPECTRUM_SOLO_X, edit.solo}} {
+            bx, by := float32(r.X+box.x+6), float32(y+4)
+            if (box.on) {
+                vector.DrawFilledRect(screen, bx, by, SPECTRUM_ROW_HEIGHT-8, SPECTRUM_ROW_HEIGHT-8, style.Accent, false)
+            }
+            vector.StrokeRect(screen, bx, by, SPECTRUM_ROW_HEIGHT-8, SPECTRUM_ROW_HEIGHT-8, 1, style.Border, false)
+        }
+    }
+}
+
+// drawSpectrumOverlay shows the whole reconstructed drawing faintly and rings the
+// epicycle of the selected term, so an edit shows at once wherever the run is.
+func (g *Game) drawSpectrumOverlay(screen *ebiten.Image) {
+    s := &g.spectrum
+    path := g.camera.ToScreenPath(g.model.Path)
+    for i := range path {
+        a, b := path[i], path[(i+1)%len(path)]
+        vector.StrokeLine(screen, float32(a.x), float32(a.y), float32(b.x), float32(b.y), 1, scaleAlpha(g.theme.Trail, 0.3), true)
+    }
+    if (s.selected < 0) {
+        return
+    }
+    freq := s.original[s.axis][s.selected].Freq
+    chain := g.modelAxis(s.axis).Chain(float64(g.fourierIndex))
+    for _, e := range chain.Epicycles {
+        if (e.Freq == freq) {
+            x, y := g.camera.ToScreen(e.Center.X, e.Center.Y)
+            vector.StrokeCircle(screen, float32(x), float32(y), float32(math.Max(e.Radius*g.camera.zoom, 4)), 2, g.theme.TipX, true)
+        }
+    }
+}
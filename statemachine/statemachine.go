@@ -0,0 +1,111 @@
+// Package statemachine is a small finite state machine with declared transitions,
+// guards and enter and exit hooks. It knows nothing about windows or input, so the
+// rules of an app can be exercised on their own.
+package statemachine
+
+import (
+    "fmt"
+    "log"
+)
+
+type transition[S comparable] struct {
+    from, to S
+}
+
+// Machine holds the current state of type S and the rules for leaving it.
+type Machine[S comparable] struct {
+    current     S
+    allowed     map[transition[S]]bool
+    guards      map[S][]func(from S) error
+    onEnter     map[S][]func(from S)
+    onExit      map[S][]func(to S)
+    onChange    []func(from, to S)
+    // Logf reports refused transitions; it defaults to log.Printf.
+    Logf        func(format string, args ...any)
+}
+
+func New[S comparable](initial S) *Machine[S] {
+    return &Machine[S]{
+        current:    initial,
+        allowed:    make(map[transition[S]]bool),
+        guards:     make(map[S][]func(S) error),
+        onEnter:    make(map[S][]func(S)),
+        onExit:     make(map[S][]func(S)),
+        Logf:       log.Printf,
+    }
+}
+
+func (m *Machine[S]) Current() S {
+    return m.current
+}
+
+// Allow declares the transitions from from to each of to.
+func (m *Machine[S]) Allow(from S, to ...S) {
+    for _, t := range to {
+        m.allowed[transition[S]{from, t}] = true
+    }
+}
+
+// Guard adds a condition for entering to; a non-nil error refuses the transition.
+func (m *Machine[S]) Guard(to S, guard func(from S) error) {
+    m.guards[to] = append(m.guards[to], guard)
+}
+
+// OnEnter adds a hook run after the machine enters state.
+func (m *Machine[S]) OnEnter(state S, hook func(from S)) {
+    m.onEnter[state] = append(m.onEnter[state], hook)
+}
+
+// OnExit adds a hook run before the machine leaves state.
+func (m *Machine[S]) OnExit(state S, hook func(to S)) {
+    m.onExit[state] = append(m.onExit[state], hook)
+}
+
+// OnChange adds a hook run on every transition, between the exit and enter hooks.
+func (m *Machine[S]) OnChange(hook func(from, to S)) {
+    m.onChange = append(m.onChange, hook)
+}
+
+// Allowed reports whether the transition from from to to is declared, whatever the
+// guards and the current state.
+func (m *Machine[S]) Allowed(from, to S) bool {
+    return m.allowed[transition[S]{from, to}]
+}
+
+// Check returns why the transition to to is refused, or nil.
+func (m *Machine[S]) Check(to S) error {
+    if !m.allowed[transition[S]{m.current, to}] {
+        return fmt.Errorf("no transition from %v to %v", m.current, to)
+    }
+    for _, guard := range m.guards[to] {
+        if err := guard(m.current); err != nil {
+            return fmt.Errorf("%v to %v refused: %w", m.current, to, err)
+        }
+    }
+    return nil
+}
+
+func (m *Machine[S]) Can(to S) bool {
+    return m.Check(to) == nil
+}
+
+// Go moves to to and runs the hooks. A refused transition is logged, leaves the
+// machine where it is and returns the reason.
+func (m *Machine[S]) Go(to S) error {
+    if err := m.Check(to); err != nil {
+        m.Logf("state machine: %v", err)
+        return err
+    }
+    from := m.current
+    for _, hook := range m.onExit[from] {
+        hook(to)
+    }
+    m.current = to
+    for _, hook := range m.onChange {
+        hook(from, to)
+    }
+    for _, hook := range m.onEnter[to] {
+        hook(from)
+    }
+    return nil
+}
@@ -0,0 +1,136 @@
+package statemachine
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+    "testing"
+)
+
+type state int
+const (
+    IDLE state = iota
+    RUNNING
+    PAUSED
+    DONE
+)
+
+var errBusy = errors.New("busy")
+
+// newTestMachine allows IDLE -> RUNNING, RUNNING -> PAUSED and DONE, PAUSED ->
+// RUNNING, and guards DONE with busy.
+func newTestMachine(busy *bool, events *[]string) *Machine[state] {
+    m := New(IDLE)
+    m.Logf = func(string, ...any) {}
+    m.Allow(IDLE, RUNNING)
+    m.Allow(RUNNING, PAUSED, DONE)
+    m.Allow(PAUSED, RUNNING)
+    m.Guard(DONE, func(state) error {
+        if (*busy) {
+            return errBusy
+        }
+        return nil
+    })
+    for s := IDLE; s <= DONE; s++ {
+        m.OnExit(s, func(to state) { *events = append(*events, fmt.Sprintf("exit %d->%d", s, to)) })
+        m.OnEnter(s, func(from state) { *events = append(*events, fmt.Sprintf("enter %d->%d", from, s)) })
+    }
+    m.OnChange(func(from, to state) { *events = append(*events, fmt.Sprintf("change %d->%d", from, to)) })
+    return m
+}
+
+func TestGo(t *testing.T) {
+    tests := []struct {
+        name    string
+        path    []state
+        busy    bool
+        to      state
+        ok      bool
+        want    state
+    }{
+        {"allowed", nil, false, RUNNING, true, RUNNING},
+        {"not declared", nil, false, PAUSED, false, IDLE},
+        {"to itself", nil, false, IDLE, false, IDLE},
+        {"chained", []state{RUNNING, PAUSED}, false, RUNNING, true, RUNNING},
+        {"guard passes", []state{RUNNING}, false, DONE, true, DONE},
+        {"guard vetoes", []state{RUNNING}, true, DONE, false, RUNNING},
+        {"nothing leaves the end", []state{RUNNING, DONE}, false, RUNNING, false, DONE},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var events []string
+            m := newTestMachine(&test.busy, &events)
+            for _, s := range test.path {
+                if err := m.Go(s); err != nil {
+                    t.Fatalf("setting up: %v", err)
+                }
+            }
+            events = nil
+
+            if can := m.Can(test.to); can != test.ok {
+                t.Errorf("Can(%d) = %v, want %v", test.to, can, test.ok)
+            }
+            err := m.Go(test.to)
+            if ((err == nil) != test.ok) {
+                t.Errorf("Go(%d) = %v, want ok %v", test.to, err, test.ok)
+            }
+            if (m.Current() != test.want) {
+                t.Errorf("state after Go(%d) = %d, want %d", test.to, m.Current(), test.want)
+            }
+            if (!test.ok && len(events) != 0) {
+                t.Errorf("refused transition ran hooks %v", events)
+            }
+        })
+    }
+}
+
+func TestGuardError(t *testing.T) {
+    busy := true
+    var events []string
+    m := newTestMachine(&busy, &events)
+    m.Go(RUNNING)
+    if err := m.Go(DONE); !errors.Is(err, errBusy) {
+        t.Errorf("Go(DONE) = %v, want an error wrapping %v", err, errBusy)
+    }
+    busy = false
+    if err := m.Go(DONE); err != nil {
+        t.Errorf("Go(DONE) once idle = %v", err)
+    }
+}
+
+func TestHookOrder(t *testing.T) {
+    busy := false
+    var events []string
+    m := newTestMachine(&busy, &events)
+    m.Go(RUNNING)
+    m.Go(PAUSED)
+    want := []string{
+        "exit 0->1", "change 0->1", "enter 0->1",
+        "exit 1->2", "change 1->2", "enter 1->2",
+    }
+    if (!reflect.DeepEqual(events, want)) {
+        t.Errorf("hooks ran as %v, want %v", events, want)
+    }
+}
+
+func TestRefusalIsLogged(t *testing.T) {
+    m := New(IDLE)
+    logged := 0
+    m.Logf = func(string, ...any) { logged++ }
+    m.Go(DONE)
+    if (logged != 1) {
+        t.Errorf("refused transition logged %d times, want 1", logged)
+    }
+}
+
+func TestAllowed(t *testing.T) {
+    var events []string
+    busy := true
+    m := newTestMachine(&busy, &events)
+    if (!m.Allowed(RUNNING, DONE)) {
+        t.Error("RUNNING -> DONE is declared, whatever its guard")
+    }
+    if (m.Allowed(IDLE, DONE) || m.Allowed(DONE, IDLE)) {
+        t.Error("undeclared transitions reported as allowed")
+    }
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+    "errors"
+
+    "github.com/hajimehoshi/ebiten/v2"
+
+    "fourier-drawing/statemachine"
+    "fourier-drawing/ui"
+)
+
+var stateNames = [...]string{
+    PREPARING:      "PREPARING",
+    START:          "START",
+    DRAWING:        "DRAWING",
+    REVEALING:      "REVEALING",
+    COMPUTING:      "COMPUTING",
+    FOURIER:        "FOURIER",
+    GALLERY:        "GALLERY",
+    BROWSING:       "BROWSING",
+    EDITING:        "EDITING",
+    MORPHING:       "MORPHING",
+    END:            "END",
+}
+
+func (s GameState) String() string {
+    if (s < 0 || int(s) >= len(stateNames)) {
+        return "UNKNOWN"
+    }
+    return stateNames[s]
+}
+
+var (
+    errTooFewPoints = errors.New("the drawing needs at least two points")
+    errNoPoints = errors.New("the drawing has no points")
+    errNoMorph = errors.New("no drawings to morph between")
+)
+
+// newStateMachine declares every state change of the game. g.state mirrors the
+// machine, so the rest of the game reads it as before but only changes it with
+// setState.
+func (g *Game) newStateMachine() *statemachine.Machine[GameState] {
+    m := statemachine.New(PREPARING)
+
+    m.Allow(PREPARING, START, DRAWING, REVEALING, COMPUTING, GALLERY, MORPHING)
+    m.Allow(START, DRAWING)
+    m.Allow(DRAWING, REVEALING, GALLERY, BROWSING, EDITING)
+    m.Allow(REVEALING, COMPUTING, DRAWING)
+    m.Allow(COMPUTING, FOURIER)
+    // A playlist goes from a finished drawing straight on to the next one.
+    m.Allow(FOURIER, DRAWING, REVEALING)
+    // A gallery drawing is revealed like a loaded one.
+    m.Allow(GALLERY, DRAWING, REVEALING, COMPUTING)
+    m.Allow(BROWSING, DRAWING, GALLERY, MORPHING)
+    m.Allow(EDITING, DRAWING)
+    m.Allow(MORPHING, DRAWING)
+    // END is where the game stops, whatever it was doing.
+    for s := PREPARING; s < END; s++ {
+        m.Allow(s, END)
+    }
+
+    enoughPoints := func(GameState) error {
+        if (len(g.points) < 2) {
+            return errTooFewPoints
+        }
+        return nil
+    }
+    m.Guard(REVEALING, enoughPoints)
+    m.Guard(COMPUTING, enoughPoints)
+    m.Guard(FOURIER, enoughPoints)
+    m.Guard(MORPHING, func(GameState) error {
+        if (g.morph == nil) {
+            return errNoMorph
+        }
+        return nil
+    })
+    m.Guard(EDITING, func(GameState) error {
+        if (len(g.points) == 0) {
+            return errNoPoints
+        }
+        return nil
+    })
+
+    m.OnChange(func(from, to GameState) {
+        g.state = to
+    })
+    m.OnEnter(DRAWING, func(GameState) {
+        // Leaving a playlist for the board ends it, back to the command line settings.
+        if (g.playlist != nil) {
+            g.options.speed, g.options.maxEpicycles = g.playlist.speed, g.playlist.epicycles
+            *g.widgets.Get("max-epicycles").(*ui.Slider).Value = float64(g.options.maxEpicycles)
+            g.playlist = nil
+            ebiten.SetCursorMode(ebiten.CursorModeVisible)
+        }
+    })
+    m.OnEnter(REVEALING, func(GameState) {
+        g.normalizeDrawing()
+        g.revealIndex = 0
+        g.fourierIndex = 0
+    })
+    // Drawings are normalized once, when they are loaded or revealed; here only those
+    // computed without being revealed first.
+    m.OnEnter(COMPUTING, func(from GameState) {
+        if (from != REVEALING) {
+            g.normalizeDrawing()
+        }
+    })
+    m.OnEnter(EDITING, func(GameState) {
+        g.editor.reset(len(g.points))
+    })
+    m.OnEnter(FOURIER, func(GameState) {
+        g.fourierIndex = 0
+        g.fourierTime = 0
+        g.spectrum.reset(g.model)
+    })
+    m.OnEnter(MORPHING, func(GameState) {
+        g.morph.ticks = 0
+        g.morph.blend = 0
+        g.fourierIndex = 0
+        g.fourierTime = 0
+        g.model = g.morph.model(g.windowSize.width, g.windowSize.height)
+    })
+    m.OnExit(FOURIER, func(GameState) {
+        if (g.recorder != nil) {
+            g.stopRecording()
+        }
+    })
+    return m
+}
+
+// setState asks the state machine to move to state and reports whether it did.
+// Refused transitions are logged by the machine.
+func (g *Game) setState(state GameState) bool {
+    return g.machine.Go(state) == nil
+}
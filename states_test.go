@@ -0,0 +1,60 @@
+package main
+
+import (
+    "testing"
+)
+
+// TestStateRules checks the transition table of the game, guards aside.
+func TestStateRules(t *testing.T) {
+    g := &Game{}
+    m := g.newStateMachine()
+    tests := []struct {
+        from, to    GameState
+        want        bool
+    }{
+        {PREPARING, DRAWING, true},
+        {DRAWING, REVEALING, true},
+        {REVEALING, COMPUTING, true},
+        {COMPUTING, FOURIER, true},
+        {GALLERY, REVEALING, true},
+        {GALLERY, COMPUTING, true},
+        {FOURIER, REVEALING, true},
+        {BROWSING, MORPHING, true},
+        {EDITING, DRAWING, true},
+        {DRAWING, FOURIER, false},
+        {DRAWING, COMPUTING, false},
+        {START, FOURIER, false},
+        {EDITING, REVEALING, false},
+        {MORPHING, FOURIER, false},
+        {END, DRAWING, false},
+    }
+    for _, test := range tests {
+        if got := m.Allowed(test.from, test.to); got != test.want {
+            t.Errorf("%v -> %v allowed = %v, want %v", test.from, test.to, got, test.want)
+        }
+    }
+    for s := PREPARING; s < END; s++ {
+        if (!m.Allowed(s, END)) {
+            t.Errorf("%v cannot end the game", s)
+        }
+    }
+}
+
+func TestStateGuards(t *testing.T) {
+    g := &Game{}
+    g.machine = g.newStateMachine()
+    g.machine.Logf = func(string, ...any) {}
+    g.machine.Go(DRAWING)
+    if (g.state != DRAWING) {
+        t.Fatalf("state = %v, want DRAWING", g.state)
+    }
+    if (g.setState(REVEALING)) {
+        t.Error("REVEALING entered without points")
+    }
+    if (g.setState(EDITING)) {
+        t.Error("EDITING entered without points")
+    }
+    if (g.state != DRAWING) {
+        t.Errorf("refused transitions moved the game to %v", g.state)
+    }
+}
@@ -0,0 +1,85 @@
+// Package svg writes frames of the engine as SVG documents.
+package svg
+
+import (
+    "bufio"
+    "fmt"
+    "image/color"
+    "io"
+
+    "fourier-drawing/engine"
+)
+
+// Style holds the colors of a frame. A nil color leaves its part out.
+type Style struct {
+    Background  color.Color
+    Trail       color.Color
+    Epicycles   color.Color
+    Radius      color.Color
+    TipX        color.Color
+    TipY        color.Color
+    TrailWidth  float64
+}
+
+// paint returns the SVG color and opacity of c.
+func paint(c color.Color) (string, float64) {
+    n := color.NRGBAModel.Convert(c).(color.NRGBA)
+    return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B), float64(n.A)/255
+}
+
+// WriteFrame writes frame on a width by height canvas: the circles and radii of
+// both chains, the path traced so far and the two tips.
+func WriteFrame(w io.Writer, width, height int, frame *engine.Frame, style *Style) error {
+    b := bufio.NewWriter(w)
+    fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+    if (style.Background != nil) {
+        fill, opacity := paint(style.Background)
+        fmt.Fprintf(b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\" fill-opacity=\"%.3g\"/>\n", fill, opacity)
+    }
+
+    chains := []*engine.Chain{&frame.X, &frame.Y}
+    if (style.Epicycles != nil) {
+        stroke, opacity := paint(style.Epicycles)
+        fmt.Fprintf(b, "<g fill=\"none\" stroke=\"%s\" stroke-opacity=\"%.3g\">\n", stroke, opacity)
+        for _, chain := range chains {
+            for _, e := range chain.Epicycles {
+                fmt.Fprintf(b, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"%.2f\"/>\n", e.Center.X, e.Center.Y, e.Radius)
+            }
+        }
+        fmt.Fprintf(b, "</g>\n")
+    }
+    if (style.Radius != nil) {
+        stroke, opacity := paint(style.Radius)
+        for _, chain := range chains {
+            if (len(chain.Epicycles) == 0) {
+                continue
+            }
+            fmt.Fprintf(b, "<polyline fill=\"none\" stroke=\"%s\" stroke-opacity=\"%.3g\" points=\"%.2f,%.2f", stroke, opacity, chain.Epicycles[0].Center.X, chain.Epicycles[0].Center.Y)
+            for _, e := range chain.Epicycles {
+                fmt.Fprintf(b, " %.2f,%.2f", e.End.X, e.End.Y)
+            }
+            fmt.Fprintf(b, "\"/>\n")
+        }
+    }
+    if (style.Trail != nil && len(frame.Path) >= 2) {
+        stroke, opacity := paint(style.Trail)
+        fmt.Fprintf(b, "<polyline fill=\"none\" stroke=\"%s\" stroke-opacity=\"%.3g\" stroke-width=\"%.3g\" stroke-linejoin=\"round\" stroke-linecap=\"round\" points=\"", stroke, opacity, style.TrailWidth)
+        for i, p := range frame.Path {
+            if (i > 0) {
+                b.WriteByte(' ')
+            }
+            fmt.Fprintf(b, "%.2f,%.2f", p.X, p.Y)
+        }
+        fmt.Fprintf(b, "\"/>\n")
+    }
+    for _, tip := range []struct{ at engine.Vec; clr color.Color }{{frame.X.Tip, style.TipX}, {frame.Y.Tip, style.TipY}} {
+        if (tip.clr == nil) {
+            continue
+        }
+        fill, opacity := paint(tip.clr)
+        fmt.Fprintf(b, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"6\" fill=\"%s\" fill-opacity=\"%.3g\"/>\n", tip.at.X, tip.at.Y, fill, opacity)
+    }
+
+    fmt.Fprintf(b, "</svg>\n")
+    return b.Flush()
+}
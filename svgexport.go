@@ -0,0 +1,90 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+
+    "fourier-drawing/svg"
+)
+
+// runSVG implements the "svg" subcommand: the epicycles of a drawing at one moment,
+// with the path traced until then, as an SVG file.
+func runSVG(args []string) error {
+    flags := flag.NewFlagSet("svg", flag.ExitOnError)
+    flags.Usage = func() {
+        fmt.Fprintf(flags.Output(), "Usage:\n  fourier-drawing svg [flags] <points file>\n\nFlags:\n")
+        flags.PrintDefaults()
+    }
+    output := flags.String("o", "drawing.svg", "output SVG file")
+    at := flags.Float64("t", 1, "moment to draw as a fraction of the drawing, from 0 to 1")
+    width := flags.Int("width", 1920, "canvas width")
+    height := flags.Int("height", 1080, "canvas height")
+    maxEpicycles := flags.Int("max-epicycles", 0, "number of epicycles per axis, largest first (0 uses all)")
+    byTime := flags.Bool("by-time", false, "resample timed drawings at even time steps before the transform")
+    epicycles := flags.Bool("epicycles", true, "draw the circles and radii")
+    themeName := flags.String("theme", "light", "color theme")
+    themesDir := flags.String("themes-dir", userThemesDir(), "directory with user JSON themes")
+    var filter SpectrumFilter
+    checkFilter := addFilterFlags(flags, &filter)
+    var normalization Normalization
+    checkNormalize := addNormalizeFlags(flags, &normalization)
+    var window SpectrumWindow
+    checkWindow := addWindowFlags(flags, &window)
+    flags.Parse(args)
+
+    for _, check := range []func() error{checkFilter, checkNormalize, checkWindow} {
+        if err := check(); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            flags.Usage()
+            os.Exit(2)
+        }
+    }
+    if (flags.NArg() != 1 || *at < 0 || *at > 1 || *width <= 0 || *height <= 0) {
+        flags.Usage()
+        os.Exit(2)
+    }
+
+    themes, err := loadThemes(*themesDir)
+    if err != nil {
+        return err
+    }
+    themeIndex := findTheme(themes, *themeName)
+    if (themeIndex < 0) {
+        return fmt.Errorf("unknown theme %q", *themeName)
+    }
+    theme := themes[themeIndex]
+
+    points, err := readPointsFromFile(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+    if (len(points) < 2) {
+        return fmt.Errorf("%s contains fewer than two points", flags.Arg(0))
+    }
+
+    points = normalizePoints(points, &normalization, *width, *height)
+    model := computeModel(points, *width, *height, *maxEpicycles, *byTime, &filter, &window)
+    frame := model.Frame(*at*float64(model.Len()))
+    style := &svg.Style{
+        Background: theme.Background,
+        Trail:      theme.Trail,
+        TipX:       theme.TipX,
+        TipY:       theme.TipY,
+        TrailWidth: 2,
+    }
+    if (*epicycles) {
+        style.Epicycles = theme.Epicycles
+        style.Radius = theme.Radius
+    }
+
+    file, err := os.Create(*output)
+    if err != nil {
+        return err
+    }
+    if err := svg.WriteFrame(file, *width, *height, &frame, style); err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "image/color"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "fourier-drawing/ui"
+)
+
+// HexColor is a color written as "#RRGGBB" or "#RRGGBBAA" in theme files.
+type HexColor color.RGBA
+
+func (c HexColor) RGBA() (r, g, b, a uint32) {
+    return color.RGBA(c).RGBA()
+}
+
+func (c HexColor) MarshalText() ([]byte, error) {
+    return []byte(fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)), nil
+}
+
+func (c *HexColor) UnmarshalText(text []byte) error {
+    s := strings.TrimPrefix(string(text), "#")
+    var r, g, b, a uint8
+    a = 255
+    var err error
+    switch len(s) {
+    case 6:
+        _, err = fmt.Sscanf(s, "%02x%02x%02x", &r, &g, &b)
+    case 8:
+        _, err = fmt.Sscanf(s, "%02x%02x%02x%02x", &r, &g, &b, &a)
+    default:
+        err = fmt.Errorf("expected #RRGGBB or #RRGGBBAA")
+    }
+    if err != nil {
+        return fmt.Errorf("invalid color %q: %w", string(text), err)
+    }
+    *c = HexColor{r, g, b, a}
+    return nil
+}
+
+// Theme holds every color used to draw the board and the UI.
+type Theme struct {
+    Name            string      `json:"name"`
+    Background      HexColor    `json:"background"`
+    Trail           HexColor    `json:"trail"`
+    Dots            HexColor    `json:"dots"`
+    Epicycles       HexColor    `json:"epicycles"`
+    Radius          HexColor    `json:"radius"`
+    TipX            HexColor    `json:"tipX"`
+    TipY            HexColor    `json:"tipY"`
+    Guides          HexColor    `json:"guides"`
+    PositiveFreq    HexColor    `json:"positiveFreq"`
+    NegativeFreq    HexColor    `json:"negativeFreq"`
+    UIBackground    HexColor    `json:"uiBackground"`
+    UIBorder        HexColor    `json:"uiBorder"`
+    UIHover         HexColor    `json:"uiHover"`
+    UIPressed       HexColor    `json:"uiPressed"`
+    UIFocus         HexColor    `json:"uiFocus"`
+    UIDisabled      HexColor    `json:"uiDisabled"`
+    UIAccent        HexColor    `json:"uiAccent"`
+    Text            HexColor    `json:"text"`
+    Error           HexColor    `json:"error"`
+}
+
+var darkTheme = Theme{
+    Name:           "dark",
+    Background:     HexColor{0, 0, 0, 255},
+    Trail:          HexColor{64, 64, 64, 64},
+    Dots:           HexColor{192, 192, 192, 255},
+    Epicycles:      HexColor{150, 150, 150, 255},
+    Radius:         HexColor{150, 150, 150, 255},
+    TipX:           HexColor{255, 0, 0, 100},
+    TipY:           HexColor{0, 255, 0, 100},
+    Guides:         HexColor{255, 255, 255, 255},
+    PositiveFreq:   HexColor{255, 170, 60, 255},
+    NegativeFreq:   HexColor{80, 170, 255, 255},
+    UIBackground:   HexColor{0, 0, 0, 255},
+    UIBorder:       HexColor{255, 255, 255, 255},
+    UIHover:        HexColor{48, 48, 48, 255},
+    UIPressed:      HexColor{96, 96, 96, 255},
+    UIFocus:        HexColor{255, 200, 0, 255},
+    UIDisabled:     HexColor{96, 96, 96, 255},
+    UIAccent:       HexColor{150, 150, 255, 255},
+    Text:           HexColor{255, 255, 255, 255},
+    Error:          HexColor{255, 128, 128, 255},
+}
+
+var lightTheme = Theme{
+    Name:           "light",
+    Background:     HexColor{245, 245, 240, 255},
+    Trail:          HexColor{40, 40, 40, 160},
+    Dots:           HexColor{90, 90, 90, 255},
+    Epicycles:      HexColor{120, 120, 140, 255},
+    Radius:         HexColor{80, 80, 100, 255},
+    TipX:           HexColor{200, 0, 0, 160},
+    TipY:           HexColor{0, 140, 0, 160},
+    Guides:         HexColor{60, 60, 60, 255},
+    PositiveFreq:   HexColor{210, 90, 0, 255},
+    NegativeFreq:   HexColor{0, 90, 200, 255},
+    UIBackground:   HexColor{255, 255, 255, 255},
+    UIBorder:       HexColor{40, 40, 40, 255},
+    UIHover:        HexColor{225, 225, 235, 255},
+    UIPressed:      HexColor{190, 190, 210, 255},
+    UIFocus:        HexColor{0, 90, 200, 255},
+    UIDisabled:     HexColor{170, 170, 170, 255},
+    UIAccent:       HexColor{40, 80, 200, 255},
+    Text:           HexColor{20, 20, 20, 255},
+    Error:          HexColor{190, 0, 0, 255},
+}
+
+// highContrastTheme uses the Okabe-Ito palette, which stays distinguishable for
+// the common forms of color blindness.
+var highContrastTheme = Theme{
+    Name:           "high-contrast",
+    Background:     HexColor{0, 0, 0, 255},
+    Trail:          HexColor{255, 255, 255, 255},
+    Dots:           HexColor{240, 228, 66, 255},
+    Epicycles:      HexColor{86, 180, 233, 255},
+    Radius:         HexColor{86, 180, 233, 255},
+    TipX:           HexColor{230, 159, 0, 255},
+    TipY:           HexColor{0, 158, 115, 255},
+    Guides:         HexColor{204, 121, 167, 255},
+    PositiveFreq:   HexColor{230, 159, 0, 255},
+    NegativeFreq:   HexColor{86, 180, 233, 255},
+    UIBackground:   HexColor{0, 0, 0, 255},
+    UIBorder:       HexColor{255, 255, 255, 255},
+    UIHover:        HexColor{0, 114, 178, 255},
+    UIPressed:      HexColor{86, 180, 233, 255},
+    UIFocus:        HexColor{240, 228, 66, 255},
+    UIDisabled:     HexColor{128, 128, 128, 255},
+    UIAccent:       HexColor{230, 159, 0, 255},
+    Text:           HexColor{255, 255, 255, 255},
+    Error:          HexColor{213, 94, 0, 255},
+}
+
+// Style returns the widget style matching the theme.
+func (t *Theme) Style() ui.Style {
+    return ui.Style{
+        Background: t.UIBackground,
+        Border:     t.UIBorder,
+        Text:       t.Text,
+        Hover:      t.UIHover,
+        Pressed:    t.UIPressed,
+        Focus:      t.UIFocus,
+        Disabled:   t.UIDisabled,
+        Accent:     t.UIAccent,
+        TextSize:   ui.DEFAULT_TEXT_SIZE,
+    }
+}
+
+func userThemesDir() string {
+    dir, err := os.UserConfigDir()
+    if err != nil {
+        return ""
+    }
+    return filepath.Join(dir, "fourier-drawing", "themes")
+}
+
+// loadThemes returns the built-in themes followed by the user JSON themes found in dir.
+// A user theme starts from the dark theme, so it only needs the colors it changes;
+// a user theme with the name of a built-in one replaces it.
+func loadThemes(dir string) ([]*Theme, error) {
+    builtIn := []Theme{darkTheme, lightTheme, highContrastTheme}
+    themes := make([]*Theme, len(builtIn))
+    for i := range builtIn {
+        themes[i] = &builtIn[i]
+    }
+    if dir == "" {
+        return themes, nil
+    }
+
+    files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+    if err != nil {
+        return themes, err
+    }
+    sort.Strings(files)
+    for _, file := range files {
+        data, err := os.ReadFile(file)
+        if err != nil {
+            return themes, err
+        }
+        theme := darkTheme
+        theme.Name = strings.TrimSuffix(filepath.Base(file), ".json")
+        if err := json.Unmarshal(data, &theme); err != nil {
+            return themes, fmt.Errorf("%s: %w", file, err)
+        }
+        if i := findTheme(themes, theme.Name); i >= 0 {
+            themes[i] = &theme
+        } else {
+            themes = append(themes, &theme)
+        }
+    }
+    return themes, nil
+}
+
+func findTheme(themes []*Theme, name string) int {
+    for i, theme := range themes {
+        if strings.EqualFold(theme.Name, name) {
+            return i
+        }
+    }
+    return -1
+}
+
+func (g *Game) setTheme(index int) {
+    g.themeIndex = (index+len(g.themes))%len(g.themes)
+    g.theme = g.themes[g.themeIndex]
+    g.style = g.theme.Style()
+    if g.widgets != nil {
+        g.redrawThumbnails()
+        g.widgets.Get("theme").(*ui.Button).Label = "Theme: " + g.theme.Name
+    }
+}
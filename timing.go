@@ -0,0 +1,57 @@
+package main
+
+import (
+    "math"
+
+    "github.com/hajimehoshi/ebiten/v2"
+)
+
+// STROKE_GAP_LIMIT caps, in seconds, the time recorded between two samples, so a
+// pause between strokes does not stall the playback.
+const STROKE_GAP_LIMIT = 0.5
+
+// capturePoint stamps a newly drawn point with the drawing time. The time is counted
+// in ticks rather than read from the clock, so replayed input draws the same points.
+func (g *Game) capturePoint(x, y float64) Point {
+    t := 0.0
+    if (len(g.points) > 0) {
+        elapsed := float64(g.ticks-g.lastCaptureTick)/float64(ebiten.TPS())
+        t = g.points[len(g.points)-1].t+math.Min(elapsed, STROKE_GAP_LIMIT)
+    }
+    g.lastCaptureTick = g.ticks
+    return Point{x: x, y: y, t: t, pressure: 1}
+}
+
+func hasTiming(points []Point) bool {
+    return len(points) > 1 && points[len(points)-1].t > points[0].t
+}
+
+// resampleByTime returns as many points as given, spread evenly over the drawing
+// time by linear interpolation. Drawings without timing are returned as they are.
+func resampleByTime(points []Point) []Point {
+    if (!hasTiming(points)) {
+        return points
+    }
+    n := len(points)
+    start, duration := points[0].t, points[n-1].t-points[0].t
+    out := make([]Point, n)
+    i := 0
+    for j := range out {
+        t := start+duration*float64(j)/float64(n)
+        for i < n-2 && points[i+1].t <= t {
+            i++
+        }
+        a, b := points[i], points[i+1]
+        f := 0.0
+        if (b.t > a.t) {
+            f = math.Max(0, math.Min(1, (t-a.t)/(b.t-a.t)))
+        }
+        out[j] = Point{
+            x:          a.x+(b.x-a.x)*f,
+            y:          a.y+(b.y-a.y)*f,
+            t:          t,
+            pressure:   a.pressure+(b.pressure-a.pressure)*f,
+        }
+    }
+    return out
+}
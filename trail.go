@@ -0,0 +1,177 @@
+package main
+
+import (
+    "image"
+    "image/color"
+    "math"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/ui"
+)
+
+type TrailStyle int
+const (
+    SOLID_TRAIL TrailStyle = iota
+    COMET_TRAIL
+    GRADIENT_TRAIL
+    SPEED_TRAIL
+    GLOW_TRAIL
+    TRAIL_STYLES
+)
+
+var trailStyleNames = [TRAIL_STYLES]string{"solid", "comet", "gradient", "speed", "glow"}
+
+func trailStyleByName(name string) (TrailStyle, bool) {
+    for i, n := range trailStyleNames {
+        if n == name {
+            return TrailStyle(i), true
+        }
+    }
+    return 0, false
+}
+
+var whiteImage = ebiten.NewImage(3, 3)
+
+// whiteSubImage is the source of every trail triangle; the vertex colors do the rest.
+var whiteSubImage = whiteImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+
+func init() {
+    whiteImage.Fill(color.White)
+}
+
+// trailBatch collects the strokes of many segments, each with its own color and width,
+// so a whole trail is drawn with a handful of DrawTriangles calls.
+type trailBatch struct {
+    vertices    []ebiten.Vertex
+    indices     []uint16
+    path        vector.Path
+}
+
+func (b *trailBatch) addSegment(screen *ebiten.Image, x0, y0, x1, y1 float64, width float32, clr color.Color, blend ebiten.Blend) {
+    // Indices are uint16, so flush before the vertex count can overflow them.
+    if len(b.vertices) > math.MaxUint16-64 {
+        b.flush(screen, blend)
+    }
+
+    b.path = vector.Path{}
+    b.path.MoveTo(float32(x0), float32(y0))
+    b.path.LineTo(float32(x1), float32(y1))
+    start := len(b.vertices)
+    b.vertices, b.indices = b.path.AppendVerticesAndIndicesForStroke(b.vertices, b.indices, &vector.StrokeOptions{
+        Width:      width,
+        LineCap:    vector.LineCapRound,
+    })
+
+    r, g, bl, a := clr.RGBA()
+    for i := start; i < len(b.vertices); i++ {
+        b.vertices[i].SrcX = 1
+        b.vertices[i].SrcY = 1
+        if a == 0 {
+            b.vertices[i].ColorR, b.vertices[i].ColorG, b.vertices[i].ColorB, b.vertices[i].ColorA = 0, 0, 0, 0
+            continue
+        }
+        // RGBA returns premultiplied values, the vertices expect straight alpha.
+        b.vertices[i].ColorR = float32(r)/float32(a)
+        b.vertices[i].ColorG = float32(g)/float32(a)
+        b.vertices[i].ColorB = float32(bl)/float32(a)
+        b.vertices[i].ColorA = float32(a)/0xffff
+    }
+}
+
+func (b *trailBatch) flush(screen *ebiten.Image, blend ebiten.Blend) {
+    if len(b.indices) > 0 {
+        opts := &ebiten.DrawTrianglesOptions{
+            FillRule:   ebiten.FillRuleNonZero,
+            AntiAlias:  true,
+            Blend:      blend,
+        }
+        screen.DrawTriangles(b.vertices, b.indices, whiteSubImage, opts)
+    }
+    b.vertices = b.vertices[:0]
+    b.indices = b.indices[:0]
+}
+
+// hueColor returns a fully saturated color with hue h in [0, 1).
+func hueColor(h float64, alpha uint8) color.RGBA {
+    h = (h-math.Floor(h))*6
+    x := uint8(255*(1-math.Abs(math.Mod(h, 2)-1)))
+    switch int(h) {
+    case 0:
+        return color.RGBA{255, x, 0, alpha}
+    case 1:
+        return color.RGBA{x, 255, 0, alpha}
+    case 2:
+        return color.RGBA{0, 255, x, alpha}
+    case 3:
+        return color.RGBA{0, x, 255, alpha}
+    case 4:
+        return color.RGBA{x, 0, 255, alpha}
+    default:
+        return color.RGBA{255, 0, x, alpha}
+    }
+}
+
+func withAlpha(c HexColor, alpha float64) color.RGBA {
+    return color.RGBA{c.R, c.G, c.B, uint8(math.Max(0, math.Min(255, alpha*255)))}
+}
+
+func (g *Game) setTrailStyle(style TrailStyle) {
+    g.trailStyle = (style+TRAIL_STYLES)%TRAIL_STYLES
+    g.widgets.Get("trail").(*ui.Button).Label = "Trail: " + trailStyleNames[g.trailStyle]
+}
+
+// drawTrail strokes points[0..end) in the given style. cometLength is the number of
+// segments kept by COMET_TRAIL.
+func drawTrail(screen *ebiten.Image, points []Point, end int, style TrailStyle, cometLength int, theme *Theme) {
+    end = min(end, len(points))
+    if end < 2 {
+        return
+    }
+
+    var batch trailBatch
+    blend := ebiten.BlendSourceOver
+    trailColor := theme.Trail
+
+    switch style {
+    case SOLID_TRAIL:
+        for i:=1; i<end; i++ {
+            batch.addSegment(screen, points[i-1].x, points[i-1].y, points[i].x, points[i].y, 1.5, trailColor, blend)
+        }
+    case COMET_TRAIL:
+        start := max(1, end-cometLength)
+        for i:=start; i<end; i++ {
+            fade := float64(i-start+1)/float64(end-start)
+            batch.addSegment(screen, points[i-1].x, points[i-1].y, points[i].x, points[i].y, float32(1+2*fade), withAlpha(trailColor, fade), blend)
+        }
+    case GRADIENT_TRAIL:
+        for i:=1; i<end; i++ {
+            batch.addSegment(screen, points[i-1].x, points[i-1].y, points[i].x, points[i].y, 2, hueColor(float64(i)/float64(len(points)), 255), blend)
+        }
+    case SPEED_TRAIL:
+        total := 0.0
+        for i:=1; i<len(points); i++ {
+            total += math.Hypot(points[i].x-points[i-1].x, points[i].y-points[i-1].y)
+        }
+        mean := math.Max(total/float64(len(points)-1), 1e-9)
+        for i:=1; i<end; i++ {
+            speed := math.Hypot(points[i].x-points[i-1].x, points[i].y-points[i-1].y)/mean
+            width := 3*math.Max(0.3, math.Min(3, speed))
+            batch.addSegment(screen, points[i-1].x, points[i-1].y, points[i].x, points[i].y, float32(width), withAlpha(trailColor, 1), blend)
+        }
+    case GLOW_TRAIL:
+        // Wide faint halos added on top of each other, then a thin bright core.
+        for _, pass := range []struct{ width float32; alpha float64 }{{14, 0.08}, {7, 0.18}} {
+            for i:=1; i<end; i++ {
+                batch.addSegment(screen, points[i-1].x, points[i-1].y, points[i].x, points[i].y, pass.width, withAlpha(theme.Dots, pass.alpha), ebiten.BlendLighter)
+            }
+            batch.flush(screen, ebiten.BlendLighter)
+        }
+        for i:=1; i<end; i++ {
+            batch.addSegment(screen, points[i-1].x, points[i-1].y, points[i].x, points[i].y, 2, withAlpha(theme.Dots, 1), blend)
+        }
+    }
+
+    batch.flush(screen, blend)
+}
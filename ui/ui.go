@@ -0,0 +1,333 @@
+// Package ui is a small widget toolkit drawn with Ebiten: buttons, toggles,
+// sliders, labels and panels kept in a Registry.
+package ui
+
+import (
+    "bytes"
+    "image/color"
+    "log"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/text/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "golang.org/x/image/font/gofont/goregular"
+)
+
+const DEFAULT_TEXT_SIZE = 18.0
+
+var fontSource *text.GoTextFaceSource
+
+func init() {
+    source, err := text.NewGoTextFaceSource(bytes.NewReader(goregular.TTF))
+    if err != nil {
+        log.Fatal(err)
+    }
+    fontSource = source
+}
+
+// Face returns the UI font at the given pixel size.
+func Face(size float64) *text.GoTextFace {
+    return &text.GoTextFace{Source: fontSource, Size: size}
+}
+
+// DrawText draws str with its top-left corner at (x, y).
+func DrawText(screen *ebiten.Image, str string, size, x, y float64, clr color.Color) {
+    opts := &text.DrawOptions{}
+    opts.GeoM.Translate(x, y)
+    opts.ColorScale.ScaleWithColor(clr)
+    opts.LineSpacing = size*1.3
+    text.Draw(screen, str, Face(size), opts)
+}
+
+// MeasureText returns the size of str drawn at the given pixel size.
+func MeasureText(str string, size float64) (width, height float64) {
+    return text.Measure(str, Face(size), size*1.3)
+}
+
+type Rect struct {
+    X, Y, W, H float64
+}
+
+func (r Rect) Contains(x, y float64) bool {
+    return x >= r.X && x <= r.X+r.W && y >= r.Y && y <= r.Y+r.H
+}
+
+// Style holds the colors used to draw widgets.
+type Style struct {
+    Background  color.Color
+    Border      color.Color
+    Text        color.Color
+    Hover       color.Color
+    Pressed     color.Color
+    Focus       color.Color
+    Disabled    color.Color
+    Accent      color.Color
+    TextSize    float64
+}
+
+var DefaultStyle = Style{
+    Background: color.RGBA{0, 0, 0, 255},
+    Border:     color.RGBA{255, 255, 255, 255},
+    Text:       color.RGBA{255, 255, 255, 255},
+    Hover:      color.RGBA{48, 48, 48, 255},
+    Pressed:    color.RGBA{96, 96, 96, 255},
+    Focus:      color.RGBA{255, 200, 0, 255},
+    Disabled:   color.RGBA{96, 96, 96, 255},
+    Accent:     color.RGBA{150, 150, 255, 255},
+    TextSize:   DEFAULT_TEXT_SIZE,
+}
+
+// Input is the pointer and keyboard state a Registry needs for one tick.
+type Input struct {
+    X, Y            float64
+    Pressed         bool
+    JustPressed     bool
+    JustReleased    bool
+    FocusNext       bool
+    FocusPrevious   bool
+    Activate        bool
+    Increase        bool
+    Decrease        bool
+}
+
+// Base carries the state shared by every widget.
+type Base struct {
+    ID          string
+    Bounds      Rect
+    // Screens lists the screens the widget is shown on; empty means every screen.
+    Screens     []int
+    Hidden      bool
+    // DisabledIf is evaluated every tick; nil means always enabled.
+    DisabledIf  func() bool
+    hovered     bool
+    pressed     bool
+    focused     bool
+}
+
+func (b *Base) base() *Base {
+    return b
+}
+
+func (b *Base) Disabled() bool {
+    return b.DisabledIf != nil && b.DisabledIf()
+}
+
+func (b *Base) Hovered() bool {
+    return b.hovered
+}
+
+func (b *Base) Focused() bool {
+    return b.focused
+}
+
+func (b *Base) onScreen(screen int) bool {
+    if b.Hidden {
+        return false
+    }
+    if len(b.Screens) == 0 {
+        return true
+    }
+    for _, s := range b.Screens {
+        if s == screen {
+            return true
+        }
+    }
+    return false
+}
+
+// trackClick updates hover and press state and reports a completed click:
+// the pointer was pressed and released inside the widget.
+func (b *Base) trackClick(in *Input) (clicked bool) {
+    b.hovered = b.Bounds.Contains(in.X, in.Y)
+    if b.Disabled() {
+        b.pressed = false
+        return false
+    }
+    if in.JustPressed && b.hovered {
+        b.pressed = true
+    }
+    if in.JustReleased || !in.Pressed {
+        clicked = b.pressed && b.hovered
+        b.pressed = false
+    }
+    return clicked
+}
+
+type Widget interface {
+    base() *Base
+    // Update handles the input and reports whether the widget captured the pointer.
+    Update(in *Input) bool
+    Draw(screen *ebiten.Image, style *Style)
+    // Focusable reports whether Tab can move the keyboard focus to the widget.
+    Focusable() bool
+}
+
+func drawFrame(screen *ebiten.Image, b *Base, style *Style) {
+    r := b.Bounds
+    border := style.Border
+    if b.focused {
+        border = style.Focus
+    } else if b.Disabled() {
+        border = style.Disabled
+    }
+    fill := style.Background
+    if b.pressed {
+        fill = style.Pressed
+    } else if b.hovered && !b.Disabled() {
+        fill = style.Hover
+    }
+    vector.DrawFilledRect(screen, float32(r.X), float32(r.Y), float32(r.W), float32(r.H), fill, false)
+    vector.StrokeRect(screen, float32(r.X), float32(r.Y), float32(r.W), float32(r.H), 3, border, false)
+}
+
+func textColor(b *Base, style *Style) color.Color {
+    if b.Disabled() {
+        return style.Disabled
+    }
+    return style.Text
+}
+
+// Registry holds every widget of the app and dispatches input, focus and drawing
+// to the ones shown on the current screen.
+type Registry struct {
+    widgets []Widget
+    byID    map[string]Widget
+    focus   Widget
+}
+
+func NewRegistry() *Registry {
+    return &Registry{byID: make(map[string]Widget)}
+}
+
+// Add registers w; the children of a panel can be looked up by ID as well.
+func (r *Registry) Add(w Widget) Widget {
+    r.widgets = append(r.widgets, w)
+    for _, child := range flatten([]Widget{w}) {
+        if id := child.base().ID; id != "" {
+            r.byID[id] = child
+        }
+    }
+    return w
+}
+
+func (r *Registry) Get(id string) Widget {
+    return r.byID[id]
+}
+
+// RemoveIf drops every widget for which remove returns true.
+func (r *Registry) RemoveIf(remove func(w Widget) bool) {
+    kept := r.widgets[:0]
+    for _, w := range r.widgets {
+        if remove(w) {
+            delete(r.byID, w.base().ID)
+            if r.focus == w {
+                r.focus = nil
+            }
+            continue
+        }
+        kept = append(kept, w)
+    }
+    r.widgets = kept
+}
+
+func (r *Registry) visible(screen int) []Widget {
+    var widgets []Widget
+    for _, w := range r.widgets {
+        if w.base().onScreen(screen) {
+            widgets = append(widgets, w)
+        }
+    }
+    return widgets
+}
+
+// flatten lists the widgets together with the children of their panels.
+func flatten(widgets []Widget) []Widget {
+    var all []Widget
+    for _, w := range widgets {
+        all = append(all, w)
+        if p, ok := w.(*Panel); ok {
+            var children []Widget
+            for _, child := range p.Children {
+                if !child.base().Hidden {
+                    children = append(children, child)
+                }
+            }
+            all = append(all, flatten(children)...)
+        }
+    }
+    return all
+}
+
+func (r *Registry) moveFocus(widgets []Widget, step int) {
+    var focusable []Widget
+    current := -1
+    for _, w := range flatten(widgets) {
+        if w.Focusable() && !w.base().Disabled() {
+            if w == r.focus {
+                current = len(focusable)
+            }
+            focusable = append(focusable, w)
+        }
+    }
+    if len(focusable) == 0 {
+        r.focus = nil
+        return
+    }
+    if current < 0 {
+        if step > 0 {
+            current = -1
+        } else {
+            current = 0
+        }
+    }
+    r.focus = focusable[(current+step+len(focusable))%len(focusable)]
+}
+
+// Update runs one tick for the widgets on screen and reports whether the pointer
+// is captured by one of them, so the caller can ignore it.
+func (r *Registry) Update(in *Input, screen int) (captured bool) {
+    widgets := r.visible(screen)
+
+    if r.focus != nil && !r.focusVisible(widgets) {
+        r.focus = nil
+    }
+    if in.FocusNext {
+        r.moveFocus(widgets, 1)
+    } else if in.FocusPrevious {
+        r.moveFocus(widgets, -1)
+    }
+    for _, w := range flatten(r.widgets) {
+        w.base().focused = w == r.focus
+    }
+
+    for _, w := range widgets {
+        if w.Update(in) {
+            captured = true
+        }
+    }
+    return captured
+}
+
+func (r *Registry) focusVisible(widgets []Widget) bool {
+    if r.focus.base().Disabled() {
+        return false
+    }
+    for _, w := range flatten(widgets) {
+        if w == r.focus {
+            return true
+        }
+    }
+    return false
+}
+
+// Focused returns the widget holding the keyboard focus, if any.
+func (r *Registry) Focused() Widget {
+    return r.focus
+}
+
+func (r *Registry) Draw(screen *ebiten.Image, current int, style *Style) {
+    for _, w := range r.visible(current) {
+        w.Draw(screen, style)
+    }
+}
@@ -0,0 +1,276 @@
+package ui
+
+import (
+    "fmt"
+    "image/color"
+    "math"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type Button struct {
+    Base
+    Label       string
+    // TextSize overrides the style text size when it is not 0.
+    TextSize    float64
+    // Image is drawn centred below the label, e.g. a thumbnail.
+    Image       *ebiten.Image
+    OnClick     func()
+}
+
+func (b *Button) Focusable() bool {
+    return true
+}
+
+func (b *Button) Update(in *Input) bool {
+    clicked := b.trackClick(in)
+    if b.focused && in.Activate && !b.Disabled() {
+        clicked = true
+    }
+    if clicked && b.OnClick != nil {
+        b.OnClick()
+    }
+    return b.hovered || b.pressed
+}
+
+func (b *Button) Draw(screen *ebiten.Image, style *Style) {
+    drawFrame(screen, &b.Base, style)
+    r := b.Bounds
+    size := b.TextSize
+    if size == 0 {
+        size = style.TextSize
+    }
+    w, h := MeasureText(b.Label, size)
+    if b.Image == nil {
+        DrawText(screen, b.Label, size, r.X+(r.W-w)/2, r.Y+(r.H-h)/2, textColor(&b.Base, style))
+        return
+    }
+    DrawText(screen, b.Label, size, r.X+(r.W-w)/2, r.Y+8, textColor(&b.Base, style))
+    imageW, imageH := b.Image.Bounds().Dx(), b.Image.Bounds().Dy()
+    opts := &ebiten.DrawImageOptions{}
+    opts.GeoM.Translate(r.X+(r.W-float64(imageW))/2, r.Y+h+16+(r.H-h-16-float64(imageH))/2)
+    if b.Disabled() {
+        opts.ColorScale.ScaleAlpha(0.4)
+    }
+    screen.DrawImage(b.Image, opts)
+}
+
+// Toggle is a labelled check box bound to a bool.
+type Toggle struct {
+    Base
+    Label       string
+    Value       *bool
+    OnChange    func(value bool)
+}
+
+func (t *Toggle) Focusable() bool {
+    return true
+}
+
+func (t *Toggle) Update(in *Input) bool {
+    clicked := t.trackClick(in)
+    if t.focused && in.Activate && !t.Disabled() {
+        clicked = true
+    }
+    if clicked {
+        *t.Value = !*t.Value
+        if t.OnChange != nil {
+            t.OnChange(*t.Value)
+        }
+    }
+    return t.hovered || t.pressed
+}
+
+func (t *Toggle) Draw(screen *ebiten.Image, style *Style) {
+    drawFrame(screen, &t.Base, style)
+    r := t.Bounds
+    box := r.H-16
+    vector.StrokeRect(screen, float32(r.X+8), float32(r.Y+8), float32(box), float32(box), 2, textColor(&t.Base, style), false)
+    if *t.Value {
+        vector.DrawFilledRect(screen, float32(r.X+12), float32(r.Y+12), float32(box-8), float32(box-8), style.Accent, false)
+    }
+    _, h := MeasureText(t.Label, style.TextSize)
+    DrawText(screen, t.Label, style.TextSize, r.X+box+18, r.Y+(r.H-h)/2, textColor(&t.Base, style))
+}
+
+// Slider edits a float64 between Min and Max, snapped to Step when Step > 0.
+type Slider struct {
+    Base
+    Label       string
+    Min, Max    float64
+    Step        float64
+    Value       *float64
+    Format      string
+    OnChange    func(value float64)
+    dragging    bool
+}
+
+func (s *Slider) Focusable() bool {
+    return true
+}
+
+func (s *Slider) track() Rect {
+    r := s.Bounds
+    return Rect{r.X+12, r.Y+r.H-22, r.W-24, 10}
+}
+
+func (s *Slider) set(value float64) {
+    if s.Step > 0 {
+        value = s.Min+math.Round((value-s.Min)/s.Step)*s.Step
+    }
+    value = math.Max(s.Min, math.Min(s.Max, value))
+    if value != *s.Value {
+        *s.Value = value
+        if s.OnChange != nil {
+            s.OnChange(value)
+        }
+    }
+}
+
+func (s *Slider) Update(in *Input) bool {
+    s.hovered = s.Bounds.Contains(in.X, in.Y)
+    if s.Disabled() {
+        s.dragging = false
+        return s.hovered
+    }
+    if in.JustPressed && s.hovered {
+        s.dragging = true
+    }
+    if !in.Pressed {
+        s.dragging = false
+    }
+    s.pressed = s.dragging
+    if s.dragging {
+        t := s.track()
+        s.set(s.Min+(in.X-t.X)/t.W*(s.Max-s.Min))
+    }
+    if s.focused {
+        step := s.Step
+        if step <= 0 {
+            step = (s.Max-s.Min)/100
+        }
+        if in.Increase {
+            s.set(*s.Value+step)
+        } else if in.Decrease {
+            s.set(*s.Value-step)
+        }
+    }
+    return s.hovered || s.dragging
+}
+
+func (s *Slider) Draw(screen *ebiten.Image, style *Style) {
+    drawFrame(screen, &s.Base, style)
+    r := s.Bounds
+    format := s.Format
+    if format == "" {
+        format = "%.2f"
+    }
+    DrawText(screen, s.Label+": "+fmt.Sprintf(format, *s.Value), style.TextSize, r.X+12, r.Y+6, textColor(&s.Base, style))
+
+    t := s.track()
+    vector.DrawFilledRect(screen, float32(t.X), float32(t.Y+t.H/2-1), float32(t.W), 2, textColor(&s.Base, style), false)
+    knobX := t.X
+    if s.Max > s.Min {
+        knobX += (*s.Value-s.Min)/(s.Max-s.Min)*t.W
+    }
+    vector.DrawFilledCircle(screen, float32(knobX), float32(t.Y+t.H/2), 7, style.Accent, true)
+}
+
+// Label shows static text, or the result of TextFunc when it is set.
+type Label struct {
+    Base
+    Text        string
+    TextFunc    func() string
+    Size        float64
+    Color       color.Color
+}
+
+func (l *Label) Focusable() bool {
+    return false
+}
+
+func (l *Label) Update(in *Input) bool {
+    return false
+}
+
+func (l *Label) Draw(screen *ebiten.Image, style *Style) {
+    str := l.Text
+    if l.TextFunc != nil {
+        str = l.TextFunc()
+    }
+    size := l.Size
+    if size == 0 {
+        size = style.TextSize
+    }
+    clr := l.Color
+    if clr == nil {
+        clr = style.Text
+    }
+    DrawText(screen, str, size, l.Bounds.X, l.Bounds.Y, clr)
+}
+
+// Panel draws a framed background and stacks its children vertically inside it.
+type Panel struct {
+    Base
+    Title       string
+    Children    []Widget
+    Padding     float64
+    Spacing     float64
+}
+
+func (p *Panel) Focusable() bool {
+    return false
+}
+
+func (p *Panel) Add(w Widget) Widget {
+    p.Children = append(p.Children, w)
+    p.Layout()
+    return w
+}
+
+// Layout positions the visible children top to bottom, stretched to the panel width.
+func (p *Panel) Layout() {
+    y := p.Bounds.Y+p.Padding
+    if p.Title != "" {
+        y += DEFAULT_TEXT_SIZE*1.3+p.Spacing
+    }
+    for _, child := range p.Children {
+        b := child.base()
+        if b.Hidden {
+            continue
+        }
+        b.Bounds.X = p.Bounds.X+p.Padding
+        b.Bounds.Y = y
+        b.Bounds.W = p.Bounds.W-2*p.Padding
+        y += b.Bounds.H+p.Spacing
+    }
+    p.Bounds.H = y-p.Spacing+p.Padding-p.Bounds.Y
+}
+
+func (p *Panel) Update(in *Input) bool {
+    captured := p.Bounds.Contains(in.X, in.Y)
+    for _, child := range p.Children {
+        if child.base().Hidden {
+            continue
+        }
+        if child.Update(in) {
+            captured = true
+        }
+    }
+    return captured
+}
+
+func (p *Panel) Draw(screen *ebiten.Image, style *Style) {
+    r := p.Bounds
+    vector.DrawFilledRect(screen, float32(r.X), float32(r.Y), float32(r.W), float32(r.H), style.Background, false)
+    vector.StrokeRect(screen, float32(r.X), float32(r.Y), float32(r.W), float32(r.H), 2, style.Border, false)
+    if p.Title != "" {
+        DrawText(screen, p.Title, style.TextSize, r.X+p.Padding, r.Y+p.Padding, style.Text)
+    }
+    for _, child := range p.Children {
+        if !child.base().Hidden {
+            child.Draw(screen, style)
+        }
+    }
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "image/color"
+    "math"
+    "strings"
+
+    "github.com/hajimehoshi/ebiten/v2"
+    "github.com/hajimehoshi/ebiten/v2/vector"
+
+    "fourier-drawing/fourier"
+    "fourier-drawing/ui"
+)
+
+type WindowKind int
+const (
+    RECTANGULAR_WINDOW WindowKind = iota
+    HANN_WINDOW
+    HAMMING_WINDOW
+    BLACKMAN_WINDOW
+    TUKEY_WINDOW
+    WINDOW_KINDS
+)
+
+var windowKindNames = [WINDOW_KINDS]string{"rectangular", "hann", "hamming", "blackman", "tukey"}
+
+func windowKindByName(name string) (WindowKind, bool) {
+    for i, n := range windowKindNames {
+        if n == name {
+            return WindowKind(i), true
+        }
+    }
+    return 0, false
+}
+
+// SpectrumWindow is the window the sequences are weighted by before the transform.
+type SpectrumWindow struct {
+    kind        WindowKind
+    // tukeyAlpha is the fraction of the drawing the tukey window tapers over.
+    tukeyAlpha  float64
+}
+
+// function returns the window, or nil for the rectangular one, which changes nothing.
+func (w *SpectrumWindow) function() fourier.Window {
+    if (w == nil || w.kind == RECTANGULAR_WINDOW) {
+        return nil
+    }
+    return windowFunction(w.kind, w.tukeyAlpha)
+}
+
+// addWindowFlags registers the window flags on flags. The returned function checks
+// and stores them once the flags are parsed.
+func addWindowFlags(flags *flag.FlagSet, w *SpectrumWindow) func() error {
+    var kind string
+    flags.StringVar(&kind, "window", "rectangular", "window applied to the sequences before the transform, and compared with the rectangular one in the leakage view: rectangular, hann, hamming, blackman or tukey")
+    flags.Float64Var(&w.tukeyAlpha, "tukey-alpha", 0.5, "fraction of the drawing the tukey window tapers over, from 0 to 1")
+
+    return func() error {
+        var ok bool
+        w.kind, ok = windowKindByName(strings.ToLower(kind))
+        if (!ok) {
+            return fmt.Errorf("invalid -window value %q", kind)
+        }
+        if (w.tukeyAlpha < 0 || w.tukeyAlpha > 1) {
+            return fmt.Errorf("-tukey-alpha must be from 0 to 1")
+        }
+        return nil
+    }
+}
+
+// windowedTransform returns the spectrum of sequence weighted by window, sorted by
+// module. Only the deviations from the mean are weighted, so the drawing tapers
+// towards its own centre rather than the board's, and the constant term stays exact.
+func windowedTransform(sequence []float64, window fourier.Window) []fourier.FourierElement {
+    if (window == nil || len(sequence) == 0) {
+        return fourier.DiscreteFourierTransform(sequence, true)
+    }
+    m := mean(sequence)
+    deviations := append([]float64(nil), sequence...)
+    shiftSequence(deviations, -m)
+    X := fourier.WindowedDFT(deviations, window, false)
+    X[0].Val += complex(m*float64(len(sequence)), 0)
+    fourier.SortByModule(X)
+    return X
+}
+
+func windowFunction(kind WindowKind, tukeyAlpha float64) fourier.Window {
+    switch kind {
+    case HANN_WINDOW:
+        return fourier.Hann
+    case HAMMING_WINDOW:
+        return fourier.Hamming
+    case BLACKMAN_WINDOW:
+        return fourier.Blackman
+    case TUKEY_WINDOW:
+        return fourier.Tukey(tukeyAlpha)
+    }
+    return fourier.Rectangular
+}
+
+const (
+    // LEAKAGE_BINS is the number of frequencies shown, from 0.
+    LEAKAGE_BINS = 64
+    // LEAKAGE_FLOOR is the bottom of the plot in dB below the largest amplitude.
+    LEAKAGE_FLOOR = 80.0
+)
+
+// LeakageView compares the amplitude spectrum of the drawing without a window, where
+// the jump between the ends of an open stroke leaks into every frequency, with the
+// one through the selected window. It is computed again when the points change.
+type LeakageView struct {
+    open        bool
+    stale       bool
+    // The points, window and alpha the spectra were computed for.
+    pointsLen   int
+    first       *Point
+    kind        WindowKind
+    tukeyAlpha  float64
+    // rectangular and windowed are the amplitudes of both axes together.
+    rectangular []float64
+    windowed    []float64
+    // gap is the distance between the first and the last point.
+    gap         float64
+}
+
+func (g *Game) setWindowKind(kind WindowKind) {
+    g.options.window.kind = (kind+WINDOW_KINDS)%WINDOW_KINDS
+    g.widgets.Get("window-kind").(*ui.Button).Label = "Window: " + windowKindNames[g.options.window.kind]
+}
+
+func (g *Game) setLeakageOpen(open bool) {
+    g.leakage.open = open
+    g.widgets.Get("leakage").(*ui.Panel).Hidden = !open
+}
+
+// updateLeakage computes the spectra again if the points or the window changed.
+func (g *Game) updateLeakage() {
+    l := &g.leakage
+    var first *Point
+    if (len(g.points) > 0) {
+        first = &g.points[0]
+    }
+    if (!l.stale && l.pointsLen == len(g.points) && l.first == first && l.kind == g.options.window.kind && l.tukeyAlpha == g.options.window.tukeyAlpha) {
+        return
+    }
+    l.stale = false
+    l.pointsLen, l.first, l.kind, l.tukeyAlpha = len(g.points), first, g.options.window.kind, g.options.window.tukeyAlpha
+    l.rectangular, l.windowed, l.gap = nil, nil, 0
+    if (len(g.points) < 2) {
+        return
+    }
+
+    // The means are taken out first, so the constant term does not leak as well.
+    sequenceX := make([]float64, len(g.points))
+    sequenceY := make([]float64, len(g.points))
+    for i, p := range g.points {
+        sequenceX[i] = p.x
+        sequenceY[i] = p.y
+    }
+    shiftSequence(sequenceX, -mean(sequenceX))
+    shiftSequence(sequenceY, -mean(sequenceY))
+
+    window := windowFunction(l.kind, l.tukeyAlpha)
+    combine := func(window fourier.Window) []float64 {
+        x := fourier.AmplitudeSpectrum(sequenceX, window, LEAKAGE_BINS)
+        y := fourier.AmplitudeSpectrum(sequenceY, window, LEAKAGE_BINS)
+        for k := range x {
+            x[k] = math.Hypot(x[k], y[k])
+        }
+        return x
+    }
+    l.rectangular = combine(fourier.Rectangular)
+    l.windowed = combine(window)
+    last := g.points[len(g.points)-1]
+    l.gap = math.Hypot(last.x-g.points[0].x, last.y-g.points[0].y)
+}
+
+func mean(sequence []float64) float64 {
+    sum := 0.0
+    for _, v := range sequence {
+        sum += v
+    }
+    return sum/float64(len(sequence))
+}
+
+// LeakagePlot draws the spectra of the leakage view in dB, rectangular window in the
+// disabled color and the selected one in the accent color.
+type LeakagePlot struct {
+    ui.Base
+    g           *Game
+}
+
+func (p *LeakagePlot) Focusable() bool {
+    return false
+}
+
+func (p *LeakagePlot) Update(in *ui.Input) bool {
+    p.g.updateLeakage()
+    return p.Bounds.Contains(in.X, in.Y)
+}
+
+func (p *LeakagePlot) Draw(screen *ebiten.Image, style *ui.Style) {
+    l := &p.g.leakage
+    r := p.Bounds
+    size := style.TextSize*0.85
+    plot := ui.Rect{X: r.X+60, Y: r.Y+4, W: r.W-64, H: r.H-56}
+    vector.StrokeRect(screen, float32(plot.X), float32(plot.Y), float32(plot.W), float32(plot.H), 1, style.Border, false)
+    ui.DrawText(screen, "0 dB", size, r.X, plot.Y, style.Text)
+    ui.DrawText(screen, fmt.Sprintf("-%.0f", LEAKAGE_FLOOR), size, r.X, plot.Y+plot.H-16, style.Text)
+    ui.DrawText(screen, "0", size, plot.X, plot.Y+plot.H+4, style.Text)
+    ui.DrawText(screen, "frequency", size, plot.X+plot.W/2-40, plot.Y+plot.H+4, style.Text)
+    ui.DrawText(screen, fmt.Sprint(LEAKAGE_BINS-1), size, plot.X+plot.W-20, plot.Y+plot.H+4, style.Text)
+
+    if (len(l.rectangular) < 2) {
+        ui.DrawText(screen, "Draw at least two points", size, plot.X+10, plot.Y+10, style.Disabled)
+        return
+    }
+    top := 0.0
+    for k := range l.rectangular {
+        top = math.Max(top, math.Max(l.rectangular[k], l.windowed[k]))
+    }
+    if (top == 0) {
+        return
+    }
+    for _, curve := range []struct{ amplitudes []float64; clr color.Color }{{l.rectangular, style.Disabled}, {l.windowed, style.Accent}} {
+        var x0, y0 float32
+        for k, a := range curve.amplitudes {
+            dB := math.Max(-LEAKAGE_FLOOR, 20*math.Log10(a/top))
+            x := float32(plot.X+float64(k)/float64(len(curve.amplitudes)-1)*plot.W)
+            y := float32(plot.Y-dB/LEAKAGE_FLOOR*plot.H)
+            if (k > 0) {
+                vector.StrokeLine(screen, x0, y0, x, y, 1.5, curve.clr, true)
+            }
+            x0, y0 = x, y
+        }
+    }
+
+    closed := "closed stroke"
+    if (l.gap > 1) {
+        closed = fmt.Sprintf("ends %.0f px apart", l.gap)
+    }
+    ui.DrawText(screen, "rectangular", size, plot.X, plot.Y+plot.H+24, style.Disabled)
+    ui.DrawText(screen, windowKindNames[l.kind], size, plot.X+110, plot.Y+plot.H+24, style.Accent)
+    ui.DrawText(screen, closed, size, plot.X+200, plot.Y+plot.H+24, style.Text)
+}